@@ -1,16 +1,36 @@
 package main
 
 import (
+	"bufio"
 	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
 
 	"github.com/golang-migrate/migrate/v4"
 	_ "github.com/golang-migrate/migrate/v4/database/sqlite3"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
+
+	"url-shortener/internal/lib/aead"
+	"url-shortener/internal/localauth"
+	"url-shortener/internal/storage/sqlite"
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "rotate-key":
+			rotateKey(os.Args[2:])
+			return
+		case "add-user":
+			addUser(os.Args[2:])
+			return
+		}
+	}
+
 	var storagePath, migrationsPath, migrationsTable string
 
 	flag.StringVar(&storagePath, "storage-path", "", "path to storage")
@@ -39,6 +59,106 @@ func main() {
 	fmt.Println("migrations applied successfully")
 }
 
+// rotateKey re-encrypts client credentials with the new key, values sealed with the old key
+// or stored in plaintext by older versions are read and sealed again in one transaction
+func rotateKey(args []string) {
+	var storagePath, keyFile, oldKeyFile string
+
+	flags := flag.NewFlagSet("rotate-key", flag.ExitOnError)
+	flags.StringVar(&storagePath, "storage-path", "", "path to storage")
+	flags.StringVar(&keyFile, "key-file", "", "file with the new key, ENCRYPTION_KEY is used if empty")
+	flags.StringVar(&oldKeyFile, "old-key-file", "", "file with the key the credentials are encrypted with now")
+	_ = flags.Parse(args)
+
+	if storagePath == "" {
+		panic("storage-path is required")
+	}
+
+	var key aead.Key
+	var err error
+	if keyFile != "" {
+		key, err = aead.LoadKey("", keyFile)
+	} else {
+		key, err = aead.LoadKey(os.Getenv("ENCRYPTION_KEY"), "")
+	}
+	if err != nil {
+		panic(err)
+	}
+
+	var previous []aead.Key
+	if oldKeyFile != "" {
+		oldKey, err := aead.LoadKey("", oldKeyFile)
+		if err != nil {
+			panic(err)
+		}
+		previous = append(previous, oldKey)
+	}
+
+	storage, err := sqlite.New(storagePath, aead.NewKeyring(key, previous...))
+	if err != nil {
+		panic(err)
+	}
+	defer func() { _ = storage.Close() }()
+
+	count, err := storage.ReencryptClients()
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Printf("%d clients encrypted with key %s\n", count, key.ID)
+}
+
+// addUser creates a user of the local auth mode, the password is read from USER_PASSWORD
+// or the first line of stdin so it doesn't appear in the process list
+func addUser(args []string) {
+	var storagePath, email string
+	var level int
+
+	flags := flag.NewFlagSet("add-user", flag.ExitOnError)
+	flags.StringVar(&storagePath, "storage-path", "", "path to storage")
+	flags.StringVar(&email, "email", "", "email of the user")
+	flags.IntVar(&level, "level", 1, "level of the user, admins have level above 1")
+	_ = flags.Parse(args)
+
+	if storagePath == "" {
+		panic("storage-path is required")
+	}
+	if email == "" {
+		panic("email is required")
+	}
+	if level < 1 || level > math.MaxInt8 {
+		panic("level must be from 1 to 127")
+	}
+
+	password := os.Getenv("USER_PASSWORD")
+	if password == "" {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			panic(err)
+		}
+		password = strings.TrimRight(line, "\r\n")
+	}
+
+	hash, err := localauth.HashPassword(password)
+	if err != nil {
+		panic(err)
+	}
+
+	// the user table has no encrypted columns
+	storage, err := sqlite.New(storagePath, nil)
+	if err != nil {
+		panic(err)
+	}
+	defer func() { _ = storage.Close() }()
+
+	id, err := storage.SaveUser(localauth.NormalizeEmail(email), hash, int8(level))
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Printf("user %s added with id %d\n", localauth.NormalizeEmail(email), id)
+}
+
 func validateFlags(storagePath, migrationsPath string) {
 	if storagePath == "" {
 		panic("storage-path is required")
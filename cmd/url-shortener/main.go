@@ -14,15 +14,26 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 
+	ssocache "url-shortener/internal/clients/sso/cache"
 	ssogrpc "url-shortener/internal/clients/sso/grpc"
 	"url-shortener/internal/config"
+	"url-shortener/internal/http-server/api"
+	"url-shortener/internal/http-server/handlers/auth/login"
 	"url-shortener/internal/http-server/handlers/redirect"
-	"url-shortener/internal/http-server/handlers/url/delete"
-	"url-shortener/internal/http-server/handlers/url/save"
+	"url-shortener/internal/http-server/handlers/url/qr"
 	"url-shortener/internal/http-server/middleware/auth"
+	"url-shortener/internal/http-server/middleware/domain"
 	"url-shortener/internal/http-server/middleware/logger"
+	"url-shortener/internal/http-server/middleware/ready"
+	"url-shortener/internal/lib/aead"
+	"url-shortener/internal/lib/aliaspolicy"
+	"url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/clientip"
+	"url-shortener/internal/lib/geoip"
+	"url-shortener/internal/lib/jwt"
 	"url-shortener/internal/lib/logger/handlers/slogpretty"
 	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/localauth"
 	"url-shortener/internal/storage/sqlite"
 )
 
@@ -39,38 +50,112 @@ func main() {
 	log.Info(fmt.Sprintf("starting %s", cfg.AppName), slog.String("env", cfg.Env), slog.String("version", "1"))
 	log.Debug("debug messages are enabled")
 
-	storage, err := sqlite.New(cfg.StoragePath)
+	// only the credentials of SSO are encrypted, so the key is not required in the local auth mode
+	var sealer sqlite.Sealer
+	if cfg.Auth.Mode == config.AuthModeSSO {
+		keyring, err := setupKeyring(cfg.Encryption)
+		if err != nil {
+			log.Error("failed to load encryption key", sl.Err(err))
+			os.Exit(1)
+		}
+		sealer = keyring
+	}
+
+	storage, err := sqlite.New(cfg.StoragePath, sealer)
 	if err != nil {
 		log.Error("failed to init storage", sl.Err(err))
 		os.Exit(1)
 	}
 
-	ssoClient, err := ssogrpc.New(
-		context.Background(),
-		log,
-		cfg.Clients.SSO.Address,
-		cfg.Clients.SSO.Timeout,
-		cfg.Clients.SSO.RetriesCount,
-		cfg.AppName,
-		storage,
-		storage,
-	)
+	locator, err := setupLocator(cfg.GeoIP)
+	if err != nil {
+		log.Error("failed to init geoip", sl.Err(err))
+		os.Exit(1)
+	}
+
+	policy, err := aliaspolicy.New(cfg.AliasPolicy)
+	if err != nil {
+		log.Error("failed to init alias policy", sl.Err(err))
+		os.Exit(1)
+	}
+
+	keySet, err := setupKeySet(log, cfg.JWT.JWKS)
 	if err != nil {
-		log.Error("internal error, exits the application", sl.Err(err))
+		log.Error("failed to init jwks", sl.Err(err))
 		os.Exit(1)
 	}
-	cfg.UserKey = ssoClient.UserKey
+	if keySet != nil {
+		jwksCtx, stopJWKS := context.WithCancel(context.Background())
+		defer stopJWKS()
+		go keySet.Run(jwksCtx)
+	}
+
+	// the HS256 secret is set by the auth mode
+	verifier, err := jwt.New("", keySet, cfg.JWT)
+	if err != nil {
+		log.Error("failed to init token verifier", sl.Err(err))
+		os.Exit(1)
+	}
+
+	var (
+		permProvider  auth.PermissionProvider
+		authenticator login.Authenticator
+		degraded      = auth.DegradedDeny
+		isReady       = func() bool { return true }
+	)
+
+	switch cfg.Auth.Mode {
+	case config.AuthModeSSO:
+		ssoCtx, stopSSO := context.WithCancel(context.Background())
+		defer stopSSO()
+
+		ssoClient, err := setupSSO(ssoCtx, log, cfg, storage, verifier)
+		if err != nil {
+			log.Error("internal error, exits the application", sl.Err(err))
+			os.Exit(1)
+		}
+
+		degraded, err = auth.ParseDegradedMode(cfg.Clients.SSO.DegradedMode)
+		if err != nil {
+			log.Error("invalid sso config", sl.Err(err))
+			os.Exit(1)
+		}
+
+		permProvider = ssocache.New(ssoClient, cfg.Clients.SSO.AdminCache)
+		isReady = ssoClient.Ready
+	case config.AuthModeLocal:
+		localAuth, err := setupLocalAuth(log, cfg, storage, verifier)
+		if err != nil {
+			log.Error("failed to init local auth", sl.Err(err))
+			os.Exit(1)
+		}
+
+		permProvider = localAuth
+		authenticator = localAuth
+	default:
+		log.Error("unknown auth mode", slog.String("mode", cfg.Auth.Mode))
+		os.Exit(1)
+	}
+
+	log.Info("auth mode selected", slog.String("mode", cfg.Auth.Mode))
 
 	router := chi.NewRouter()
 	router.Use(middleware.RequestID)
 	router.Use(logger.New(log))
-	router.Use(auth.New(log, cfg.UserKey, ssoClient))
+	router.Use(response.Legacy(cfg.LegacyErrors))
+	router.Use(domain.New(log, cfg.Domains))
+	router.Use(auth.New(log, verifier, permProvider, storage, degraded))
 	router.Use(middleware.Recoverer)
-	router.Use(middleware.URLFormat)
 
-	router.Post("/", save.New(log, storage, cfg))
-	router.Delete("/{alias}", delete.New(log, storage))
-	router.Get("/{alias}", redirect.New(log, storage))
+	router.With(ready.New(log, isReady)).Route(api.Prefix, api.Routes(log, storage, cfg, policy, authenticator))
+
+	router.Group(qr.Routes(log, storage, cfg.BaseURL))
+
+	redirectHandler := redirect.New(log, storage, storage, locator)
+	router.Get("/{alias}", redirectHandler)
+	router.Head("/{alias}", redirectHandler)
+	router.Get("/{alias}/*", redirectHandler)
+	router.Head("/{alias}/*", redirectHandler)
 
 	log.Info("starting server", slog.String("address", cfg.Address))
 
@@ -115,6 +200,96 @@ func main() {
 	log.Info("server stopped")
 }
 
+func setupLocator(cfg config.GeoIP) (*geoip.Locator, error) {
+	trusted, err := clientip.ParseNetworks(cfg.TrustedProxies)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.Path == "" {
+		return geoip.NewLocator(nil, trusted), nil
+	}
+
+	db, err := geoip.Open(cfg.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	return geoip.NewLocator(db, trusted), nil
+}
+
+// setupSSO connects to SSO, the app is registered in background if it has no credentials yet
+// and its user key becomes the HS256 secret of the verifier once it is known
+func setupSSO(
+	ctx context.Context,
+	log *slog.Logger,
+	cfg *config.Config,
+	storage *sqlite.Storage,
+	verifier *jwt.Verifier,
+) (*ssogrpc.Client, error) {
+	ssoClient, err := ssogrpc.New(ctx, log, cfg.Clients.SSO, cfg.AppName, storage, storage)
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+		select {
+		case <-ssoClient.Registered():
+			verifier.SetSecret([]byte(ssoClient.UserKey()))
+		case <-ctx.Done():
+		}
+	}()
+
+	return ssoClient, nil
+}
+
+// setupLocalAuth checks passwords of the users stored in the database and signs their tokens
+// with the configured secret, the verifier accepts them with the same secret
+func setupLocalAuth(
+	log *slog.Logger,
+	cfg *config.Config,
+	storage *sqlite.Storage,
+	verifier *jwt.Verifier,
+) (*localauth.Auth, error) {
+	secret := []byte(cfg.Auth.Local.Secret)
+
+	issuer, err := jwt.NewIssuer(secret, cfg.JWT, cfg.Auth.Local.TokenTTL)
+	if err != nil {
+		return nil, err
+	}
+	verifier.SetSecret(secret)
+
+	return localauth.New(log, storage, issuer)
+}
+
+// setupKeyring seals with the current key and opens values sealed with the previous keys too
+func setupKeyring(cfg config.Encryption) (*aead.Keyring, error) {
+	key, err := aead.LoadKey(cfg.Key, cfg.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	previous := make([]aead.Key, 0, len(cfg.PreviousKeyFiles))
+	for _, path := range cfg.PreviousKeyFiles {
+		k, err := aead.LoadKey("", path)
+		if err != nil {
+			return nil, err
+		}
+		previous = append(previous, k)
+	}
+
+	return aead.NewKeyring(key, previous...), nil
+}
+
+// setupKeySet returns nil if JWKS is not configured
+func setupKeySet(log *slog.Logger, cfg config.JWKS) (*jwt.KeySet, error) {
+	if cfg.URL == "" && cfg.Path == "" {
+		return nil, nil
+	}
+
+	return jwt.NewKeySet(log, cfg)
+}
+
 func setupLogger(env string) *slog.Logger {
 	var log *slog.Logger
 	switch env {
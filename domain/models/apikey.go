@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// APIKey is a long-lived credential of a user, only the hash of its value is stored
+type APIKey struct {
+	ID    int64
+	UID   int64
+	Email string
+	Name  string
+	// Prefix is the beginning of the value shown to tell keys apart
+	Prefix string
+	Hash   []byte
+	Scopes []string
+	// ExpiresAt is zero for keys that never expire
+	ExpiresAt time.Time
+	// LastUsedAt is zero for keys that were never used
+	LastUsedAt time.Time
+	CreatedAt  time.Time
+}
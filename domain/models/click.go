@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// Click is a single redirect of a link visitor
+type Click struct {
+	URLID int64
+	// VariantID is the variant the visitor was sent to, zero if the link has no variants
+	VariantID int64
+	// Country is ISO 3166-1 alpha-2 code of the visitor, empty if unknown
+	Country   string
+	CreatedAt time.Time
+}
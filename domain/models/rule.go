@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// Rule is a conditional destination of a link. Empty conditions match any request
+type Rule struct {
+	ID int64
+	// Platform is the visitor platform detected from User-Agent
+	Platform string
+	// Language is the most preferred language tag from Accept-Language
+	Language string
+	// StartsAt and EndsAt bound the time window of the rule, zero values mean no bound
+	StartsAt time.Time
+	EndsAt   time.Time
+	// ReferrerHost matches the Referer host and its subdomains
+	ReferrerHost string
+	// Country is ISO 3166-1 alpha-2 code of the visitor resolved from its IP address
+	Country string
+	URL     string
+}
@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+type URL struct {
+	ID int64
+	// Domain is the host the alias is resolved on, empty for the default domain
+	Domain string
+	Alias  string
+	URL    string
+	// RedirectCode is the HTTP status code used to redirect to URL
+	RedirectCode int
+	// CacheMaxAge is the lifetime of the redirect in client caches in seconds,
+	// negative value means that no caching headers are sent
+	CacheMaxAge int
+	// Passthrough enables merging of the request query and trailing path into URL
+	Passthrough bool
+	// QueryConflict is the rule of resolving query keys present both in URL and in the request
+	QueryConflict string
+	// OwnerUID is the user who created the link, zero for links created before ownership
+	OwnerUID int64
+	// Workspace sharing the link with its members, nil if the link is not shared
+	Workspace *Workspace
+	// UTMTemplate is the template whose parameters are added to URL, nil if not attached
+	UTMTemplate *UTMTemplate
+	// Rules are evaluated in order, URL is used as fallback if none of them matches
+	Rules []Rule
+	// Variants split the traffic by weight if none of the rules matches
+	Variants  []Variant
+	CreatedAt time.Time
+}
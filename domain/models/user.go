@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// User is an account of the local auth mode, the level is the same as the one SSO issues
+type User struct {
+	ID        int64
+	Email     string
+	PassHash  []byte
+	Level     int8
+	CreatedAt time.Time
+}
@@ -0,0 +1,31 @@
+package models
+
+import "net/url"
+
+// UTMTemplate is a named set of UTM parameters attached to links of a campaign
+type UTMTemplate struct {
+	ID       int64
+	Name     string
+	Source   string
+	Medium   string
+	Campaign string
+	Term     string
+	Content  string
+}
+
+// Params returns non-empty UTM parameters of the template as a query
+func (t UTMTemplate) Params() url.Values {
+	params := url.Values{}
+	for key, value := range map[string]string{
+		"utm_source":   t.Source,
+		"utm_medium":   t.Medium,
+		"utm_campaign": t.Campaign,
+		"utm_term":     t.Term,
+		"utm_content":  t.Content,
+	} {
+		if value != "" {
+			params.Set(key, value)
+		}
+	}
+	return params
+}
@@ -0,0 +1,9 @@
+package models
+
+// Variant is one of the weighted destinations a link splits its traffic across
+type Variant struct {
+	ID     int64
+	URL    string
+	Weight int
+	Clicks int64
+}
@@ -0,0 +1,10 @@
+package models
+
+import "time"
+
+// Workspace is an isolated namespace of links shared by its members
+type Workspace struct {
+	ID        int64
+	Name      string
+	CreatedAt time.Time
+}
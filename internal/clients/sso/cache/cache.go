@@ -0,0 +1,115 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"url-shortener/internal/config"
+)
+
+// PermissionProvider is the lookup behind the cache
+type PermissionProvider interface {
+	IsAdmin(ctx context.Context, email string) (bool, error)
+}
+
+// AdminCache keeps the admin status of users by email for the TTL, users who are not admins are kept
+// for the negative TTL. Failed lookups are not cached, the least recently used entry is evicted on overflow
+type AdminCache struct {
+	provider    PermissionProvider
+	ttl         time.Duration
+	negativeTTL time.Duration
+	size        int
+	now         func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	// order has the most recently used entry at the front
+	order *list.List
+}
+
+type entry struct {
+	email     string
+	isAdmin   bool
+	expiresAt time.Time
+}
+
+func New(provider PermissionProvider, cfg config.AdminCache) *AdminCache {
+	return &AdminCache{
+		provider:    provider,
+		ttl:         cfg.TTL,
+		negativeTTL: cfg.NegativeTTL,
+		size:        cfg.Size,
+		now:         time.Now,
+		entries:     make(map[string]*list.Element),
+		order:       list.New(),
+	}
+}
+
+func (c *AdminCache) IsAdmin(ctx context.Context, email string) (bool, error) {
+	const op = "clients.sso.cache.IsAdmin"
+
+	if isAdmin, ok := c.get(email); ok {
+		return isAdmin, nil
+	}
+
+	isAdmin, err := c.provider.IsAdmin(ctx, email)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	c.set(email, isAdmin)
+
+	return isAdmin, nil
+}
+
+func (c *AdminCache) get(email string) (bool, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[email]
+	if !ok {
+		return false, false
+	}
+
+	e := el.Value.(*entry)
+	if !c.now().Before(e.expiresAt) {
+		c.remove(el)
+		return false, false
+	}
+	c.order.MoveToFront(el)
+
+	return e.isAdmin, true
+}
+
+func (c *AdminCache) set(email string, isAdmin bool) {
+	ttl := c.ttl
+	if !isAdmin {
+		ttl = c.negativeTTL
+	}
+	if ttl <= 0 || c.size <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e := &entry{email: email, isAdmin: isAdmin, expiresAt: c.now().Add(ttl)}
+	if el, ok := c.entries[email]; ok {
+		el.Value = e
+		c.order.MoveToFront(el)
+		return
+	}
+
+	for c.order.Len() >= c.size {
+		c.remove(c.order.Back())
+	}
+	c.entries[email] = c.order.PushFront(e)
+}
+
+func (c *AdminCache) remove(el *list.Element) {
+	c.order.Remove(el)
+	delete(c.entries, el.Value.(*entry).email)
+}
@@ -0,0 +1,108 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/config"
+)
+
+type provider struct {
+	admins map[string]bool
+	err    error
+	calls  map[string]int
+}
+
+func (p *provider) IsAdmin(_ context.Context, email string) (bool, error) {
+	p.calls[email]++
+	return p.admins[email], p.err
+}
+
+func newCache(cfg config.AdminCache) (*AdminCache, *provider, *time.Time) {
+	p := &provider{admins: map[string]bool{"admin@example.com": true}, calls: map[string]int{}}
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	c := New(p, cfg)
+	c.now = func() time.Time { return now }
+
+	return c, p, &now
+}
+
+func TestAdminCache_TTL(t *testing.T) {
+	c, p, now := newCache(config.AdminCache{TTL: time.Minute, NegativeTTL: 10 * time.Second, Size: 10})
+	ctx := context.Background()
+
+	tests := []struct {
+		name      string
+		advance   time.Duration
+		email     string
+		want      bool
+		wantCalls int
+	}{
+		{name: "admin lookup", email: "admin@example.com", want: true, wantCalls: 1},
+		{name: "admin cached", advance: 30 * time.Second, email: "admin@example.com", want: true, wantCalls: 1},
+		{name: "admin expired", advance: 30 * time.Second, email: "admin@example.com", want: true, wantCalls: 2},
+		{name: "user lookup", email: "user@example.com", want: false, wantCalls: 1},
+		{name: "user cached", advance: 5 * time.Second, email: "user@example.com", want: false, wantCalls: 1},
+		{name: "user negative ttl expired", advance: 5 * time.Second, email: "user@example.com", want: false, wantCalls: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			*now = now.Add(tt.advance)
+
+			isAdmin, err := c.IsAdmin(ctx, tt.email)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, isAdmin)
+			assert.Equal(t, tt.wantCalls, p.calls[tt.email])
+		})
+	}
+}
+
+func TestAdminCache_ErrorsAreNotCached(t *testing.T) {
+	c, p, _ := newCache(config.AdminCache{TTL: time.Minute, NegativeTTL: time.Minute, Size: 10})
+	p.err = errors.New("unavailable")
+
+	_, err := c.IsAdmin(context.Background(), "admin@example.com")
+	require.Error(t, err)
+
+	p.err = nil
+	isAdmin, err := c.IsAdmin(context.Background(), "admin@example.com")
+	require.NoError(t, err)
+	assert.True(t, isAdmin)
+	assert.Equal(t, 2, p.calls["admin@example.com"])
+}
+
+func TestAdminCache_Size(t *testing.T) {
+	c, p, _ := newCache(config.AdminCache{TTL: time.Minute, NegativeTTL: time.Minute, Size: 2})
+	ctx := context.Background()
+
+	for _, email := range []string{"a@example.com", "b@example.com", "a@example.com", "c@example.com"} {
+		_, err := c.IsAdmin(ctx, email)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 2, c.order.Len())
+
+	// b is the least recently used one and was evicted by c
+	for _, email := range []string{"a@example.com", "c@example.com", "b@example.com"} {
+		_, err := c.IsAdmin(ctx, email)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 1, p.calls["a@example.com"])
+	assert.Equal(t, 1, p.calls["c@example.com"])
+	assert.Equal(t, 2, p.calls["b@example.com"])
+}
+
+func TestAdminCache_Disabled(t *testing.T) {
+	c, p, _ := newCache(config.AdminCache{Size: 10})
+
+	for i := 0; i < 3; i++ {
+		_, err := c.IsAdmin(context.Background(), "admin@example.com")
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 3, p.calls["admin@example.com"])
+}
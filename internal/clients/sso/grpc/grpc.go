@@ -5,8 +5,11 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"sync"
 	"time"
 	"url-shortener/domain/models"
+	"url-shortener/internal/config"
+	"url-shortener/internal/lib/breaker"
 	"url-shortener/internal/lib/logger/sl"
 	"url-shortener/internal/storage"
 
@@ -15,14 +18,24 @@ import (
 	grpcretry "github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/retry"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/status"
 )
 
+var (
+	ErrNotRegistered     = errors.New("app is not registered in sso yet")
+	ErrAlreadyRegistered = errors.New("app is already registered in sso")
+	ErrEmptyCredentials  = errors.New("sso returned empty credentials")
+)
+
 type Client struct {
 	api     ssov1.UserInfoClient
+	breaker *breaker.Breaker
+
+	mu      sync.RWMutex
 	apiKey  string
-	UserKey string
+	userKey string
+	// registered is closed once the credentials are known
+	registered chan struct{}
 }
 
 type ClientSaver interface {
@@ -33,16 +46,17 @@ type ClientGetter interface {
 	Client(name string) (models.Client, error)
 }
 
-// TODO: configure transport security
+// New connects to SSO with the stored credentials. If the app is not registered yet, it is registered
+// in background with exponential backoff until ctx is done, so the service starts while SSO is unavailable.
+// The connection uses TLS unless cfg.Insecure is set, opts are added to the dial options of both connections
 func New(
 	ctx context.Context,
 	log *slog.Logger,
-	addr string,
-	timeout time.Duration,
-	retriesCount int,
+	cfg config.Client,
 	appName string,
 	clientSaver ClientSaver,
 	clientGetter ClientGetter,
+	opts ...grpc.DialOption,
 ) (*Client, error) {
 	const op = "clients.sso.grpc.New"
 
@@ -60,84 +74,209 @@ func New(
 
 	retryOpts := []grpcretry.CallOption{
 		grpcretry.WithCodes(codes.NotFound, codes.Aborted, codes.DeadlineExceeded),
-		grpcretry.WithMax(uint(retriesCount)),
-		grpcretry.WithPerRetryTimeout(timeout),
+		grpcretry.WithMax(uint(cfg.RetriesCount)),
+		grpcretry.WithPerRetryTimeout(cfg.Timeout),
 	}
 
 	logOpts := []grpclog.Option{
 		grpclog.WithLogOnEvents(grpclog.PayloadReceived, grpclog.PayloadSent),
 	}
 
-	apiKey := client.ApiKey
-	userKey := client.UserKey
-	if !isRegistered {
-		cc, err := grpc.DialContext(ctx, addr,
-			grpc.WithTransportCredentials(insecure.NewCredentials()), //disabling transport security
-			grpc.WithChainUnaryInterceptor(
-				grpclog.UnaryClientInterceptor(InterceptorLogger(log), logOpts...),
-				grpcretry.UnaryClientInterceptor(retryOpts...),
-			),
-		)
-
-		if err != nil {
-			log.Error("connection to sso service failed", sl.Err(err))
-			return nil, fmt.Errorf("%s: %w", op, err)
-		}
+	creds, err := transportCredentials(cfg)
+	if err != nil {
+		log.Error("failed to configure transport security", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if cfg.Insecure {
+		log.Warn("transport security to sso is disabled")
+	}
 
-		apiKey, userKey, err = registerApp(ctx, cc, appName)
-		if err != nil {
-			log.Error("failed to register app", sl.Err(err))
-			return nil, fmt.Errorf("%s: %w", op, err)
-		}
+	c := &Client{
+		breaker:    breaker.New(cfg.Breaker.Threshold, cfg.Breaker.OpenTimeout),
+		registered: make(chan struct{}),
 	}
 
-	auth := &auth{apiKey: apiKey}
-	cc, err := grpc.DialContext(ctx, addr,
-		grpc.WithTransportCredentials(insecure.NewCredentials()), //disabling transport security
+	dialOpts := []grpc.DialOption{
+		grpc.WithTransportCredentials(creds),
 		grpc.WithChainUnaryInterceptor(
 			grpclog.UnaryClientInterceptor(InterceptorLogger(log), logOpts...),
 			grpcretry.UnaryClientInterceptor(retryOpts...),
 		),
-		grpc.WithPerRPCCredentials(auth),
-	)
+	}
+	dialOpts = append(dialOpts, opts...)
 
+	cc, err := grpc.DialContext(ctx, cfg.Address,
+		append(dialOpts, grpc.WithPerRPCCredentials(&auth{client: c, insecure: cfg.Insecure}))...,
+	)
 	if err != nil {
 		log.Error("connection to sso service failed", sl.Err(err))
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
+	c.api = ssov1.NewUserInfoClient(cc)
 
-	//TODO: change name insertion
-	err = clientSaver.SaveClient(name, apiKey, userKey)
+	if isRegistered {
+		c.setCredentials(client.ApiKey, client.UserKey)
+		return c, nil
+	}
+
+	regConn, err := grpc.DialContext(ctx, cfg.Address, dialOpts...)
 	if err != nil {
-		if !errors.Is(err, storage.ErrAppExists) {
-			log.Error("failed to save client", sl.Err(err))
-			return nil, fmt.Errorf("%s: %w", op, err)
+		log.Error("connection to sso service failed", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	go c.register(ctx, log, regConn, cfg.Registration, name, appName, clientSaver)
+
+	return c, nil
+}
+
+// register retries the registration with exponentially growing delays until it succeeds or ctx is done.
+// It gives up if the app is already registered, the client stays not ready since its credentials are unknown.
+// The received credentials are saved with the same retries before the client becomes ready,
+// sso doesn't return them again, so the app would stay unusable after a restart if they were lost
+func (c *Client) register(
+	ctx context.Context,
+	log *slog.Logger,
+	cc *grpc.ClientConn,
+	cfg config.Registration,
+	name string,
+	appName string,
+	clientSaver ClientSaver,
+) {
+	defer func() { _ = cc.Close() }()
+
+	var delay time.Duration
+	resetDelay := func() {
+		delay = cfg.InitialBackoff
+		if delay <= 0 {
+			delay = time.Second
 		}
 	}
+	wait := func() bool {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(delay):
+		}
 
-	grpcClient := ssov1.NewUserInfoClient(cc)
+		delay *= 2
+		if delay > cfg.MaxBackoff {
+			delay = cfg.MaxBackoff
+		}
+		return true
+	}
 
-	return &Client{
-		api:     grpcClient,
-		apiKey:  apiKey,
-		UserKey: userKey,
-	}, nil
+	var apiKey, userKey string
+	resetDelay()
+	for attempt := 1; ; attempt++ {
+		var err error
+		apiKey, userKey, err = registerApp(ctx, cc, appName)
+		if err == nil {
+			log.Info("app registered in sso", slog.Int("attempt", attempt))
+			break
+		}
+		if errors.Is(err, ErrAlreadyRegistered) {
+			// sso doesn't return credentials of registered apps, so retries can't succeed
+			log.Error("app already registered in sso, stored credentials are missing", sl.Err(err))
+			return
+		}
+
+		log.Warn("failed to register app, retrying",
+			slog.Int("attempt", attempt),
+			slog.Duration("delay", delay),
+			sl.Err(err),
+		)
+
+		if !wait() {
+			return
+		}
+	}
+
+	resetDelay()
+	for attempt := 1; ; attempt++ {
+		//TODO: change name insertion
+		err := clientSaver.SaveClient(name, apiKey, userKey)
+		if err == nil || errors.Is(err, storage.ErrAppExists) {
+			break
+		}
+
+		log.Error("failed to save client, retrying",
+			slog.Int("attempt", attempt),
+			slog.Duration("delay", delay),
+			sl.Err(err),
+		)
+
+		if !wait() {
+			return
+		}
+	}
+
+	c.setCredentials(apiKey, userKey)
+}
+
+func (c *Client) setCredentials(apiKey, userKey string) {
+	c.mu.Lock()
+	c.apiKey, c.userKey = apiKey, userKey
+	c.mu.Unlock()
+
+	close(c.registered)
 }
 
+// Registered is closed once the app credentials are available
+func (c *Client) Registered() <-chan struct{} {
+	return c.registered
+}
+
+// Ready reports whether the app credentials are available
+func (c *Client) Ready() bool {
+	select {
+	case <-c.registered:
+		return true
+	default:
+		return false
+	}
+}
+
+// UserKey is the secret of user tokens, it is empty until the app is registered
+func (c *Client) UserKey() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.userKey
+}
+
+func (c *Client) key() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.apiKey
+}
+
+// IsAdmin asks SSO for the level of the user, calls fail fast with breaker.ErrOpen while SSO keeps failing
 func (c *Client) IsAdmin(ctx context.Context, email string) (bool, error) {
 	const op = "clients.sso.grpc.Admin"
 
-	resp, err := c.api.Admin(ctx, &ssov1.AdminRequest{Email: email})
-	if err != nil {
-		if statusError, ok := status.FromError(err); ok {
-			if statusError.Code() == codes.NotFound {
-				return false, nil
+	if !c.Ready() {
+		return false, fmt.Errorf("%s: %w", op, ErrNotRegistered)
+	}
+
+	var isAdmin bool
+	err := c.breaker.Do(func() error {
+		resp, err := c.api.Admin(ctx, &ssov1.AdminRequest{Email: email})
+		if err != nil {
+			if status.Code(err) == codes.NotFound {
+				return nil
 			}
-			return false, fmt.Errorf("%s: %w", op, err)
+			return err
 		}
+		isAdmin = resp.Level > 1
+		return nil
+	})
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
 	}
 
-	return resp.Level > 1, nil
+	return isAdmin, nil
 }
 
 // InterceptorLogger adapts slog logger to interceptor logger
@@ -152,26 +291,31 @@ func registerApp(ctx context.Context, cc *grpc.ClientConn, name string) (string,
 
 	resp, err := ssov1.NewAuthClient(cc).RegisterApp(ctx, &ssov1.RegisterAppRequest{Name: name})
 	if err != nil {
-		if statusError, ok := status.FromError(err); ok {
-			if statusError.Code() != codes.AlreadyExists {
-				return "", "", fmt.Errorf("%s: %w", op, err)
-			}
+		if status.Code(err) == codes.AlreadyExists {
+			return "", "", fmt.Errorf("%s: %w", op, ErrAlreadyRegistered)
 		}
+		return "", "", fmt.Errorf("%s: %w", op, err)
+	}
+	if resp.GetApiKey() == "" || resp.GetUserKey() == "" {
+		return "", "", fmt.Errorf("%s: %w", op, ErrEmptyCredentials)
 	}
 
 	return resp.GetApiKey(), resp.GetUserKey(), nil
 }
 
+// auth sends the api key of the client, it is read on every call since the key appears after registration
 type auth struct {
-	apiKey string
+	client   *Client
+	insecure bool
 }
 
 func (a auth) GetRequestMetadata(ctx context.Context, in ...string) (map[string]string, error) {
 	return map[string]string{
-		"authorization": "Bearer " + a.apiKey,
+		"authorization": "Bearer " + a.client.key(),
 	}, nil
 }
 
+// RequireTransportSecurity keeps the api key from being sent in cleartext unless insecure mode is configured
 func (a auth) RequireTransportSecurity() bool {
-	return false
+	return !a.insecure
 }
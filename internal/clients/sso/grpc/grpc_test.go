@@ -0,0 +1,398 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+
+	"url-shortener/domain/models"
+	"url-shortener/internal/clients/sso/ssotest"
+	"url-shortener/internal/config"
+	"url-shortener/internal/lib/breaker"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/storage"
+)
+
+const appName = "url-shortener"
+
+// clients keeps credentials like the clients table, an empty one has no registered app
+type clients struct {
+	mu    sync.Mutex
+	saved map[string]models.Client
+	// saveErrs are returned by the next calls of SaveClient, one per call
+	saveErrs []error
+	saves    int
+}
+
+func newClients(stored ...models.Client) *clients {
+	c := &clients{saved: make(map[string]models.Client)}
+	for _, client := range stored {
+		c.saved[client.Name] = client
+	}
+	return c
+}
+
+func (c *clients) SaveClient(name, apiKey, userKey string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.saves++
+	if len(c.saveErrs) > 0 {
+		err := c.saveErrs[0]
+		c.saveErrs = c.saveErrs[1:]
+		return err
+	}
+	if _, ok := c.saved[name]; ok {
+		return storage.ErrAppExists
+	}
+	c.saved[name] = models.Client{Name: name, ApiKey: apiKey, UserKey: userKey}
+	return nil
+}
+
+func (c *clients) Saves() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.saves
+}
+
+func (c *clients) Client(name string) (models.Client, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	client, ok := c.saved[name]
+	if !ok {
+		return models.Client{}, storage.ErrAppNotFound
+	}
+	return client, nil
+}
+
+func testConfig() config.Client {
+	return config.Client{
+		Address:      ssotest.Address,
+		Timeout:      100 * time.Millisecond,
+		RetriesCount: 3,
+		Insecure:     true,
+		Registration: config.Registration{
+			InitialBackoff: 10 * time.Millisecond,
+			MaxBackoff:     20 * time.Millisecond,
+		},
+	}
+}
+
+func newClient(t *testing.T, ctx context.Context, srv *ssotest.Server, cfg config.Client, store *clients) *Client {
+	t.Helper()
+
+	c, err := New(ctx, slogdiscard.NewDiscardLogger(), cfg, appName, store, store, srv.Dialer())
+	require.NoError(t, err)
+
+	return c
+}
+
+func waitRegistered(t *testing.T, c *Client) {
+	t.Helper()
+
+	select {
+	case <-c.Registered():
+	case <-time.After(5 * time.Second):
+		t.Fatal("app is not registered")
+	}
+}
+
+func TestNew_Registration(t *testing.T) {
+	srv := ssotest.New()
+	t.Cleanup(srv.Close)
+	srv.AddUser("admin@example.com", 2)
+
+	unavailable := ssotest.Fault{Err: status.Error(codes.Unavailable, "sso is starting")}
+	srv.Inject(ssotest.MethodRegisterApp, unavailable, unavailable, unavailable)
+
+	store := newClients()
+	start := time.Now()
+	c := newClient(t, context.Background(), srv, testConfig(), store)
+
+	assert.False(t, c.Ready())
+	_, err := c.IsAdmin(context.Background(), "admin@example.com")
+	require.ErrorIs(t, err, ErrNotRegistered)
+
+	waitRegistered(t, c)
+	// 10ms, then 20ms twice since the backoff is capped
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+	assert.Equal(t, 4, srv.Calls(ssotest.MethodRegisterApp))
+
+	app, ok := srv.App(appName)
+	require.True(t, ok)
+	assert.Equal(t, app.UserKey, c.UserKey())
+
+	saved, err := store.Client("SSO")
+	require.NoError(t, err)
+	assert.Equal(t, app.APIKey, saved.ApiKey)
+	assert.Equal(t, app.UserKey, saved.UserKey)
+
+	// the api key received in background is sent by the connection dialed before registration
+	isAdmin, err := c.IsAdmin(context.Background(), "admin@example.com")
+	require.NoError(t, err)
+	assert.True(t, isAdmin)
+}
+
+func TestNew_StoredCredentials(t *testing.T) {
+	srv := ssotest.New()
+	t.Cleanup(srv.Close)
+	srv.AddUser("admin@example.com", 2)
+	srv.AddApp(appName, ssotest.App{APIKey: "api-key", UserKey: "user-key"})
+
+	c := newClient(t, context.Background(), srv, testConfig(),
+		newClients(models.Client{Name: "SSO", ApiKey: "api-key", UserKey: "user-key"}))
+
+	assert.True(t, c.Ready())
+	assert.Equal(t, "user-key", c.UserKey())
+
+	isAdmin, err := c.IsAdmin(context.Background(), "admin@example.com")
+	require.NoError(t, err)
+	assert.True(t, isAdmin)
+	assert.Zero(t, srv.Calls(ssotest.MethodRegisterApp))
+
+	stale := newClient(t, context.Background(), srv, testConfig(),
+		newClients(models.Client{Name: "SSO", ApiKey: "stale-key", UserKey: "user-key"}))
+	_, err = stale.IsAdmin(context.Background(), "admin@example.com")
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestNew_AlreadyRegistered(t *testing.T) {
+	srv := ssotest.New()
+	t.Cleanup(srv.Close)
+	// the app was registered by another instance or before the local database was lost
+	srv.AddApp(appName, ssotest.App{APIKey: "api-key", UserKey: "user-key"})
+
+	store := newClients()
+	c := newClient(t, context.Background(), srv, testConfig(), store)
+
+	// sso returns no credentials with AlreadyExists, so the client must not become ready with empty ones
+	require.Eventually(t, func() bool {
+		return srv.Calls(ssotest.MethodRegisterApp) >= 1
+	}, 5*time.Second, 5*time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+	assert.False(t, c.Ready())
+	assert.Empty(t, c.UserKey())
+	assert.Equal(t, 1, srv.Calls(ssotest.MethodRegisterApp), "AlreadyExists must not be retried")
+
+	_, err := store.Client("SSO")
+	require.ErrorIs(t, err, storage.ErrAppNotFound)
+
+	_, err = c.IsAdmin(context.Background(), "admin@example.com")
+	require.ErrorIs(t, err, ErrNotRegistered)
+}
+
+func TestNew_SaveFails(t *testing.T) {
+	srv := ssotest.New()
+	t.Cleanup(srv.Close)
+	srv.AddUser("admin@example.com", 2)
+
+	store := newClients()
+	locked := errors.New("database is locked")
+	store.saveErrs = []error{storage.ErrNoSealer, locked}
+
+	c := newClient(t, context.Background(), srv, testConfig(), store)
+
+	// sso doesn't return the credentials again, so the client waits for them to be stored
+	waitRegistered(t, c)
+	assert.Equal(t, 3, store.Saves())
+	assert.Equal(t, 1, srv.Calls(ssotest.MethodRegisterApp), "saving must not register the app again")
+
+	app, ok := srv.App(appName)
+	require.True(t, ok)
+	saved, err := store.Client("SSO")
+	require.NoError(t, err)
+	assert.Equal(t, app.APIKey, saved.ApiKey)
+	assert.Equal(t, app.UserKey, c.UserKey())
+
+	isAdmin, err := c.IsAdmin(context.Background(), "admin@example.com")
+	require.NoError(t, err)
+	assert.True(t, isAdmin)
+}
+
+func TestNew_SaveFailsUntilContextDone(t *testing.T) {
+	srv := ssotest.New()
+	t.Cleanup(srv.Close)
+
+	store := newClients()
+	store.saveErrs = make([]error, 1000)
+	for i := range store.saveErrs {
+		store.saveErrs[i] = storage.ErrNoSealer
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c := newClient(t, ctx, srv, testConfig(), store)
+
+	require.Eventually(t, func() bool {
+		return store.Saves() >= 2
+	}, 5*time.Second, 5*time.Millisecond)
+	assert.False(t, c.Ready(), "credentials that are not stored must not be used")
+	assert.Empty(t, c.UserKey())
+	cancel()
+
+	time.Sleep(50 * time.Millisecond)
+	saves := store.Saves()
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, saves, store.Saves())
+	assert.False(t, c.Ready())
+
+	_, err := c.IsAdmin(context.Background(), "admin@example.com")
+	require.ErrorIs(t, err, ErrNotRegistered)
+}
+
+func TestRegisterApp(t *testing.T) {
+	srv := ssotest.New()
+	t.Cleanup(srv.Close)
+	srv.AddApp(appName, ssotest.App{APIKey: "api-key", UserKey: "user-key"})
+
+	cc, err := grpc.DialContext(context.Background(), ssotest.Address,
+		grpc.WithTransportCredentials(insecure.NewCredentials()), srv.Dialer())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = cc.Close() })
+
+	apiKey, userKey, err := registerApp(context.Background(), cc, appName)
+	require.ErrorIs(t, err, ErrAlreadyRegistered)
+	assert.Empty(t, apiKey)
+	assert.Empty(t, userKey)
+
+	apiKey, userKey, err = registerApp(context.Background(), cc, "another-app")
+	require.NoError(t, err)
+	app, ok := srv.App("another-app")
+	require.True(t, ok)
+	assert.Equal(t, app.APIKey, apiKey)
+	assert.Equal(t, app.UserKey, userKey)
+}
+
+func TestNew_RegistrationStopsWithContext(t *testing.T) {
+	srv := ssotest.New()
+	t.Cleanup(srv.Close)
+
+	faults := make([]ssotest.Fault, 1000)
+	for i := range faults {
+		faults[i].Err = status.Error(codes.Unavailable, "sso is down")
+	}
+	srv.Inject(ssotest.MethodRegisterApp, faults...)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c := newClient(t, ctx, srv, testConfig(), newClients())
+
+	require.Eventually(t, func() bool {
+		return srv.Calls(ssotest.MethodRegisterApp) >= 2
+	}, 5*time.Second, 5*time.Millisecond)
+	cancel()
+
+	time.Sleep(50 * time.Millisecond)
+	calls := srv.Calls(ssotest.MethodRegisterApp)
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, calls, srv.Calls(ssotest.MethodRegisterApp))
+	assert.False(t, c.Ready())
+}
+
+func TestClient_IsAdmin(t *testing.T) {
+	tests := []struct {
+		name      string
+		email     string
+		faults    []ssotest.Fault
+		want      bool
+		wantCode  codes.Code
+		wantCalls int
+	}{
+		{name: "admin", email: "admin@example.com", want: true, wantCalls: 1},
+		{name: "user", email: "user@example.com", want: false, wantCalls: 1},
+		{
+			name:  "unknown user is not admin",
+			email: "nobody@example.com",
+			want:  false,
+			// NotFound is one of the retried codes, it is treated as not admin only after the last attempt
+			wantCalls: 3,
+		},
+		{
+			name:      "aborted call is retried",
+			email:     "admin@example.com",
+			faults:    []ssotest.Fault{{Err: status.Error(codes.Aborted, "conflict")}},
+			want:      true,
+			wantCalls: 2,
+		},
+		{
+			name:      "slow call is retried",
+			email:     "admin@example.com",
+			faults:    []ssotest.Fault{{Delay: time.Second}},
+			want:      true,
+			wantCalls: 2,
+		},
+		{
+			name:  "retries are limited",
+			email: "admin@example.com",
+			faults: []ssotest.Fault{
+				{Err: status.Error(codes.Aborted, "conflict")},
+				{Err: status.Error(codes.Aborted, "conflict")},
+				{Err: status.Error(codes.Aborted, "conflict")},
+			},
+			wantCode:  codes.Aborted,
+			wantCalls: 3,
+		},
+		{
+			name:      "unavailable is not retried",
+			email:     "admin@example.com",
+			faults:    []ssotest.Fault{{Err: status.Error(codes.Unavailable, "sso is down")}},
+			wantCode:  codes.Unavailable,
+			wantCalls: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := ssotest.New()
+			t.Cleanup(srv.Close)
+			srv.AddUser("admin@example.com", 2)
+			srv.AddUser("user@example.com", 1)
+			srv.AddApp(appName, ssotest.App{APIKey: "api-key", UserKey: "user-key"})
+			srv.Inject(ssotest.MethodAdmin, tt.faults...)
+
+			c := newClient(t, context.Background(), srv, testConfig(),
+				newClients(models.Client{Name: "SSO", ApiKey: "api-key", UserKey: "user-key"}))
+
+			isAdmin, err := c.IsAdmin(context.Background(), tt.email)
+			assert.Equal(t, tt.wantCalls, srv.Calls(ssotest.MethodAdmin))
+			if tt.wantCode != codes.OK {
+				require.Error(t, err)
+				assert.Equal(t, tt.wantCode, status.Code(err))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, isAdmin)
+		})
+	}
+}
+
+func TestClient_IsAdmin_Breaker(t *testing.T) {
+	srv := ssotest.New()
+	t.Cleanup(srv.Close)
+	srv.AddUser("admin@example.com", 2)
+	srv.AddApp(appName, ssotest.App{APIKey: "api-key", UserKey: "user-key"})
+	unavailable := ssotest.Fault{Err: status.Error(codes.Unavailable, "sso is down")}
+	srv.Inject(ssotest.MethodAdmin, unavailable, unavailable)
+
+	cfg := testConfig()
+	cfg.Breaker = config.Breaker{Threshold: 2, OpenTimeout: time.Minute}
+	c := newClient(t, context.Background(), srv, cfg,
+		newClients(models.Client{Name: "SSO", ApiKey: "api-key", UserKey: "user-key"}))
+
+	for i := 0; i < 2; i++ {
+		_, err := c.IsAdmin(context.Background(), "admin@example.com")
+		require.Error(t, err)
+	}
+
+	_, err := c.IsAdmin(context.Background(), "admin@example.com")
+	require.ErrorIs(t, err, breaker.ErrOpen)
+	assert.Equal(t, 2, srv.Calls(ssotest.MethodAdmin), "open breaker must not reach sso")
+}
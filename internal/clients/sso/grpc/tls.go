@@ -0,0 +1,68 @@
+package grpc
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"url-shortener/internal/config"
+)
+
+var (
+	ErrCertKeyPair = errors.New("cert_path and key_path must be set together")
+	ErrNoCACerts   = errors.New("no certificates in ca bundle")
+)
+
+// transportCredentials secures the connection with TLS unless insecure mode is explicitly configured
+func transportCredentials(cfg config.Client) (credentials.TransportCredentials, error) {
+	if cfg.Insecure {
+		return insecure.NewCredentials(), nil
+	}
+
+	tlsConfig, err := loadTLSConfig(cfg.TLS)
+	if err != nil {
+		return nil, err
+	}
+
+	return credentials.NewTLS(tlsConfig), nil
+}
+
+func loadTLSConfig(cfg config.ClientTLS) (*tls.Config, error) {
+	const op = "clients.sso.grpc.loadTLSConfig"
+
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: cfg.ServerName,
+	}
+
+	if cfg.CAPath != "" {
+		pem, err := os.ReadFile(cfg.CAPath)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("%s: %w", op, ErrNoCACerts)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if (cfg.CertPath == "") != (cfg.KeyPath == "") {
+		return nil, fmt.Errorf("%s: %w", op, ErrCertKeyPair)
+	}
+	if cfg.CertPath != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertPath, cfg.KeyPath)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
@@ -0,0 +1,213 @@
+package grpc
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/config"
+)
+
+type pki struct {
+	dir    string
+	ca     *x509.Certificate
+	caKey  *ecdsa.PrivateKey
+	caPool *x509.CertPool
+}
+
+func newPKI(t *testing.T) *pki {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	ca, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	p := &pki{dir: t.TempDir(), ca: ca, caKey: key, caPool: x509.NewCertPool()}
+	p.caPool.AddCert(ca)
+	p.write(t, "ca.pem", "CERTIFICATE", der)
+
+	return p
+}
+
+func (p *pki) write(t *testing.T, name, typ string, der []byte) string {
+	t.Helper()
+
+	path := filepath.Join(p.dir, name)
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der}), 0o600))
+
+	return path
+}
+
+// issue signs a certificate for the host, the files are named by the prefix
+func (p *pki) issue(t *testing.T, prefix, host string, usage x509.ExtKeyUsage) tls.Certificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: host},
+		DNSNames:     []string{host},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, p.ca, &key.PublicKey, p.caKey)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certPath := p.write(t, prefix+".pem", "CERTIFICATE", der)
+	keyPath := p.write(t, prefix+"-key.pem", "EC PRIVATE KEY", keyDER)
+
+	cert, err := tls.LoadX509KeyPair(certPath, keyPath)
+	require.NoError(t, err)
+
+	return cert
+}
+
+// handshake connects the client config to a server requiring client certificates signed by the CA
+func handshake(t *testing.T, p *pki, serverCert tls.Certificate, client *tls.Config) error {
+	t.Helper()
+
+	serverConn, clientConn := net.Pipe()
+	defer func() { _ = serverConn.Close() }()
+	defer func() { _ = clientConn.Close() }()
+
+	server := tls.Server(serverConn, &tls.Config{
+		Certificates: []tls.Certificate{serverCert},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    p.caPool,
+	})
+	done := make(chan error, 1)
+	go func() { done <- server.Handshake() }()
+
+	conn := tls.Client(clientConn, client)
+	if err := conn.Handshake(); err != nil {
+		_ = clientConn.Close()
+		<-done
+		return err
+	}
+	// with TLS 1.3 the server verifies the client certificate after the client is done,
+	// its alert has to be read since the pipe is unbuffered
+	go func() { _, _ = io.Copy(io.Discard, conn) }()
+
+	return <-done
+}
+
+func TestLoadTLSConfig(t *testing.T) {
+	p := newPKI(t)
+	serverCert := p.issue(t, "server", "sso.internal", x509.ExtKeyUsageServerAuth)
+	p.issue(t, "client", "url-shortener", x509.ExtKeyUsageClientAuth)
+
+	ca := filepath.Join(p.dir, "ca.pem")
+	cert := filepath.Join(p.dir, "client.pem")
+	key := filepath.Join(p.dir, "client-key.pem")
+
+	tests := []struct {
+		name         string
+		cfg          config.ClientTLS
+		wantErr      error
+		wantLoadErr  bool
+		handshakeErr bool
+	}{
+		{
+			name: "mtls",
+			cfg:  config.ClientTLS{CAPath: ca, CertPath: cert, KeyPath: key, ServerName: "sso.internal"},
+		},
+		{
+			name:         "server name mismatch",
+			cfg:          config.ClientTLS{CAPath: ca, CertPath: cert, KeyPath: key, ServerName: "other.internal"},
+			handshakeErr: true,
+		},
+		{
+			name:         "no client certificate",
+			cfg:          config.ClientTLS{CAPath: ca, ServerName: "sso.internal"},
+			handshakeErr: true,
+		},
+		{
+			name:         "untrusted server",
+			cfg:          config.ClientTLS{CertPath: cert, KeyPath: key, ServerName: "sso.internal"},
+			handshakeErr: true,
+		},
+		{
+			name:    "cert without key",
+			cfg:     config.ClientTLS{CAPath: ca, CertPath: cert},
+			wantErr: ErrCertKeyPair,
+		},
+		{
+			name:    "ca without certificates",
+			cfg:     config.ClientTLS{CAPath: key},
+			wantErr: ErrNoCACerts,
+		},
+		{
+			name:        "missing ca file",
+			cfg:         config.ClientTLS{CAPath: filepath.Join(p.dir, "missing.pem")},
+			wantLoadErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tlsConfig, err := loadTLSConfig(tt.cfg)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			if tt.wantLoadErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, uint16(tls.VersionTLS12), tlsConfig.MinVersion)
+
+			err = handshake(t, p, serverCert, tlsConfig)
+			if tt.handshakeErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestTransportCredentials(t *testing.T) {
+	creds, err := transportCredentials(config.Client{})
+	require.NoError(t, err)
+	assert.Equal(t, "tls", creds.Info().SecurityProtocol)
+
+	creds, err = transportCredentials(config.Client{Insecure: true})
+	require.NoError(t, err)
+	assert.Equal(t, "insecure", creds.Info().SecurityProtocol)
+
+	assert.True(t, auth{}.RequireTransportSecurity())
+	assert.False(t, auth{insecure: true}.RequireTransportSecurity())
+}
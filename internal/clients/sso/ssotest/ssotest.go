@@ -0,0 +1,264 @@
+package ssotest
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	ssov1 "github.com/dedmouze/protos/gen/go/sso"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// Address is the target to dial with Server.Dialer, it is not resolved
+const Address = "bufconn"
+
+// Names of the methods faults and latency are scripted for
+const (
+	MethodRegisterApp = "RegisterApp"
+	MethodUser        = "User"
+	MethodAdmin       = "Admin"
+)
+
+const bufSize = 1024 * 1024
+
+// Fault is the outcome scripted for one call: the call waits Delay and then fails with Err if it is set
+type Fault struct {
+	Delay time.Duration
+	Err   error
+}
+
+// App is the credentials of a registered app
+type App struct {
+	APIKey  string
+	UserKey string
+}
+
+type user struct {
+	id        int64
+	level     int32
+	createdAt time.Time
+}
+
+// Server is an in-memory SSO serving the Auth and UserInfo services over bufconn.
+// Calls of UserInfo require the api key of a registered app as a bearer token, like the real service
+type Server struct {
+	ssov1.UnimplementedAuthServer
+	ssov1.UnimplementedUserInfoServer
+
+	lis *bufconn.Listener
+	srv *grpc.Server
+
+	mu      sync.Mutex
+	users   map[string]user
+	apps    map[string]App
+	faults  map[string][]Fault
+	latency map[string]time.Duration
+	calls   map[string]int
+}
+
+// New starts the server, it is stopped by Close
+func New() *Server {
+	s := &Server{
+		lis:     bufconn.Listen(bufSize),
+		srv:     grpc.NewServer(),
+		users:   make(map[string]user),
+		apps:    make(map[string]App),
+		faults:  make(map[string][]Fault),
+		latency: make(map[string]time.Duration),
+		calls:   make(map[string]int),
+	}
+
+	ssov1.RegisterAuthServer(s.srv, s)
+	ssov1.RegisterUserInfoServer(s.srv, s)
+
+	go func() { _ = s.srv.Serve(s.lis) }()
+
+	return s
+}
+
+// Close stops the server and drops open connections
+func (s *Server) Close() {
+	s.srv.Stop()
+}
+
+// Dialer connects clients to the server, it must be passed to the dial of Address
+func (s *Server) Dialer() grpc.DialOption {
+	return grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+		return s.lis.DialContext(ctx)
+	})
+}
+
+// AddUser adds or updates the user, levels above 1 are admins. It returns the id of the user
+func (s *Server) AddUser(email string, level int32) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.users[email]
+	if !ok {
+		u = user{id: int64(len(s.users) + 1), createdAt: time.Now()}
+	}
+	u.level = level
+	s.users[email] = u
+
+	return u.id
+}
+
+// AddApp registers the app with known credentials, as if it was registered before the client started
+func (s *Server) AddApp(name string, app App) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.apps[name] = app
+}
+
+// App returns the credentials of the registered app
+func (s *Server) App(name string) (App, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	app, ok := s.apps[name]
+	return app, ok
+}
+
+// Inject queues faults of the method, each call takes the next one until the queue is empty
+func (s *Server) Inject(method string, faults ...Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.faults[method] = append(s.faults[method], faults...)
+}
+
+// SetLatency delays every call of the method, injected faults add their delay to it
+func (s *Server) SetLatency(method string, d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.latency[method] = d
+}
+
+// Calls returns the number of calls of the method including failed ones
+func (s *Server) Calls(method string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.calls[method]
+}
+
+func (s *Server) RegisterApp(ctx context.Context, req *ssov1.RegisterAppRequest) (*ssov1.RegisterAppResponse, error) {
+	if err := s.call(ctx, MethodRegisterApp); err != nil {
+		return nil, err
+	}
+
+	if req.GetName() == "" {
+		return nil, status.Error(codes.InvalidArgument, "name is required")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.apps[req.GetName()]; ok {
+		return nil, status.Error(codes.AlreadyExists, "app already exists")
+	}
+
+	app := App{APIKey: randomKey(), UserKey: randomKey()}
+	s.apps[req.GetName()] = app
+
+	return &ssov1.RegisterAppResponse{ApiKey: app.APIKey, UserKey: app.UserKey}, nil
+}
+
+func (s *Server) User(ctx context.Context, req *ssov1.UserRequest) (*ssov1.UserResponse, error) {
+	if err := s.call(ctx, MethodUser); err != nil {
+		return nil, err
+	}
+	if err := s.authorize(ctx); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.users[req.GetEmail()]
+	if !ok {
+		return nil, status.Error(codes.NotFound, "user not found")
+	}
+
+	return &ssov1.UserResponse{UserID: u.id, Email: req.GetEmail()}, nil
+}
+
+func (s *Server) Admin(ctx context.Context, req *ssov1.AdminRequest) (*ssov1.AdminResponse, error) {
+	if err := s.call(ctx, MethodAdmin); err != nil {
+		return nil, err
+	}
+	if err := s.authorize(ctx); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.users[req.GetEmail()]
+	if !ok {
+		return nil, status.Error(codes.NotFound, "user not found")
+	}
+
+	return &ssov1.AdminResponse{AdminID: u.id, Email: req.GetEmail(), Level: u.level}, nil
+}
+
+// call counts the call and plays its latency and the next injected fault
+func (s *Server) call(ctx context.Context, method string) error {
+	s.mu.Lock()
+	s.calls[method]++
+	delay := s.latency[method]
+	var fault Fault
+	if queue := s.faults[method]; len(queue) > 0 {
+		fault, s.faults[method] = queue[0], queue[1:]
+	}
+	s.mu.Unlock()
+
+	delay += fault.Delay
+	if delay > 0 {
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		case <-time.After(delay):
+		}
+	}
+
+	return fault.Err
+}
+
+// authorize checks the api key of the caller against registered apps
+func (s *Server) authorize(ctx context.Context) error {
+	md, _ := metadata.FromIncomingContext(ctx)
+	var key string
+	if values := md.Get("authorization"); len(values) > 0 {
+		key = strings.TrimPrefix(values[0], "Bearer ")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, app := range s.apps {
+		if key != "" && key == app.APIKey {
+			return nil
+		}
+	}
+
+	return status.Error(codes.Unauthenticated, "invalid api key")
+}
+
+func randomKey() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
@@ -13,24 +13,143 @@ type Config struct {
 	AppName     string        `yaml:"app_name" env_default:"url-shortener"`
 	Env         string        `yaml:"env" env_default:"local"`
 	StoragePath string        `yaml:"storage_path" env-required:"true"`
+	Encryption  Encryption    `yaml:"encryption"`
+	Auth        Auth          `yaml:"auth"`
 	Clients     ClientsConfig `yaml:"clients"`
-	UserKey     string        `yaml:"user_key"`
+	JWT         JWT           `yaml:"jwt"`
+	GeoIP       GeoIP         `yaml:"geoip"`
+	AliasPolicy AliasPolicy   `yaml:"alias_policy"`
 	HTTPServer  `yaml:"http_server"`
 }
 
+// Encryption is the AES-256 key of the secrets stored in the database, a base64 encoded value or a file containing it
+type Encryption struct {
+	Key     string `yaml:"key" env:"ENCRYPTION_KEY"`
+	KeyFile string `yaml:"key_file" env:"ENCRYPTION_KEY_FILE"`
+	// PreviousKeyFiles are rotated keys, values sealed with them are readable until the migrator re-encrypts them
+	PreviousKeyFiles []string `yaml:"previous_key_files"`
+}
+
 type HTTPServer struct {
-	Address      string        `yaml:"address" env-default:"localhost:8085"`
-	AliasLength  int           `yaml:"aliasLength" env-default:"6"`
+	Address     string `yaml:"address" env-default:"localhost:8085"`
+	AliasLength int    `yaml:"aliasLength" env-default:"6"`
+	// BaseURL is the scheme and host of short links encoded into QR codes, the request host is used if empty
+	BaseURL string `yaml:"base_url"`
+	// Domains are custom short link hosts, links on other hosts belong to the default domain
+	Domains []string `yaml:"domains"`
+	// LegacyErrors renders errors as {"status":"Error","error":"..."} with 200 status instead of problem details
+	LegacyErrors bool          `yaml:"legacy_errors" env-default:"false"`
 	ReadTimeout  time.Duration `yaml:"read_timeout" env-default:"5s"`
 	WriteTimeout time.Duration `yaml:"write_timeout" env-default:"5s"`
 	IdleTimeout  time.Duration `yaml:"idle_timeout" env-default:"60s"`
 }
 
+const (
+	AuthModeSSO   = "sso"
+	AuthModeLocal = "local"
+)
+
+// Auth chooses where users come from: the SSO service or the local user table
+type Auth struct {
+	Mode  string    `yaml:"mode" env:"AUTH_MODE" env-default:"sso"`
+	Local LocalAuth `yaml:"local"`
+}
+
+// LocalAuth is the issuer of tokens in the local mode, SSO is not used at all then
+type LocalAuth struct {
+	// Secret signs HS256 tokens, it must be at least 32 bytes
+	Secret   string        `yaml:"secret" env:"AUTH_SECRET"`
+	TokenTTL time.Duration `yaml:"token_ttl" env-default:"1h"`
+}
+
+// JWT are the requirements to user tokens, the HMAC secret is the user key issued by SSO
+type JWT struct {
+	Issuer   string `yaml:"issuer"`
+	Audience string `yaml:"audience"`
+	// Leeway is the allowed clock skew between SSO and the service
+	Leeway time.Duration `yaml:"leeway" env-default:"30s"`
+	// RSAPublicKeyPath and EdDSAPublicKeyPath are PEM keys accepting RS256 and EdDSA tokens
+	RSAPublicKeyPath   string `yaml:"rsa_public_key_path"`
+	EdDSAPublicKeyPath string `yaml:"eddsa_public_key_path"`
+	JWKS               JWKS   `yaml:"jwks"`
+}
+
+// JWKS is the document of rotating public keys, it is disabled if both URL and Path are empty
+type JWKS struct {
+	URL  string `yaml:"url"`
+	Path string `yaml:"path"`
+	// RefreshInterval is the period of reloading the document
+	RefreshInterval time.Duration `yaml:"refresh_interval" env-default:"15m"`
+	// MinRefreshInterval limits reloads caused by tokens with unknown kid
+	MinRefreshInterval time.Duration `yaml:"min_refresh_interval" env-default:"1m"`
+	Timeout            time.Duration `yaml:"timeout" env-default:"5s"`
+}
+
+// GeoIP is a local IP database used to resolve visitor countries
+type GeoIP struct {
+	// Path to .mmdb or .csv database, geo routing is disabled if empty
+	Path           string   `yaml:"path"`
+	TrustedProxies []string `yaml:"trusted_proxies"`
+}
+
+// AliasPolicy restricts custom aliases of links
+type AliasPolicy struct {
+	MinLength int    `yaml:"min_length" env-default:"3"`
+	MaxLength int    `yaml:"max_length" env-default:"32"`
+	Charset   string `yaml:"charset" env-default:"abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_"`
+	// Reserved are words that can't be used as aliases in addition to service route prefixes
+	Reserved []string `yaml:"reserved"`
+	// ProfanityPath is a file of words aliases must not contain, one per line
+	ProfanityPath string `yaml:"profanity_path"`
+	// CaseInsensitive makes aliases differing only in case collide
+	CaseInsensitive bool `yaml:"case_insensitive"`
+	// NormalizeLookalikes makes aliases differing only in 0/O and 1/I collide
+	NormalizeLookalikes bool `yaml:"normalize_lookalikes"`
+}
+
 type Client struct {
 	Address      string        `yaml:"address"`
 	Timeout      time.Duration `yaml:"timeout"`
 	RetriesCount int           `yaml:"retries_count"`
-	Insecure     bool          `yaml:"insecure" env-default:"false"`
+	// Insecure disables transport security, TLS is used otherwise
+	Insecure     bool         `yaml:"insecure" env-default:"false"`
+	TLS          ClientTLS    `yaml:"tls"`
+	AdminCache   AdminCache   `yaml:"admin_cache"`
+	Breaker      Breaker      `yaml:"breaker"`
+	Registration Registration `yaml:"registration"`
+	// DegradedMode is how the admin status is resolved while the service is unavailable: deny or token
+	DegradedMode string `yaml:"degraded_mode" env-default:"deny"`
+}
+
+// Registration is the backoff between attempts to register the app while the service is unavailable
+type Registration struct {
+	InitialBackoff time.Duration `yaml:"initial_backoff" env-default:"1s"`
+	MaxBackoff     time.Duration `yaml:"max_backoff" env-default:"1m"`
+}
+
+// Breaker stops calls to the service after Threshold consecutive failures for OpenTimeout, 0 threshold disables it
+type Breaker struct {
+	Threshold   int           `yaml:"threshold" env-default:"5"`
+	OpenTimeout time.Duration `yaml:"open_timeout" env-default:"30s"`
+}
+
+// ClientTLS configures the TLS connection, the system roots are trusted if CAPath is empty
+// and the client certificate is sent only if both CertPath and KeyPath are set
+type ClientTLS struct {
+	CAPath   string `yaml:"ca_path"`
+	CertPath string `yaml:"cert_path"`
+	KeyPath  string `yaml:"key_path"`
+	// ServerName overrides the host name the server certificate is verified against
+	ServerName string `yaml:"server_name"`
+}
+
+// AdminCache keeps SSO admin lookups, a zero TTL disables caching of the results
+type AdminCache struct {
+	TTL time.Duration `yaml:"ttl" env-default:"1m"`
+	// NegativeTTL is how long users who are not admins are kept
+	NegativeTTL time.Duration `yaml:"negative_ttl" env-default:"30s"`
+	// Size is the maximum number of cached users
+	Size int `yaml:"size" env-default:"10000"`
 }
 
 type ClientsConfig struct {
@@ -0,0 +1,104 @@
+package api
+
+import (
+	_ "embed"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"url-shortener/internal/config"
+	apikeydelete "url-shortener/internal/http-server/handlers/apikey/delete"
+	apikeylist "url-shortener/internal/http-server/handlers/apikey/list"
+	apikeysave "url-shortener/internal/http-server/handlers/apikey/save"
+	"url-shortener/internal/http-server/handlers/auth/login"
+	"url-shortener/internal/http-server/handlers/url/delete"
+	"url-shortener/internal/http-server/handlers/url/list"
+	"url-shortener/internal/http-server/handlers/url/save"
+	"url-shortener/internal/http-server/handlers/url/stats"
+	utmdelete "url-shortener/internal/http-server/handlers/utm/delete"
+	utmlist "url-shortener/internal/http-server/handlers/utm/list"
+	utmsave "url-shortener/internal/http-server/handlers/utm/save"
+	wslist "url-shortener/internal/http-server/handlers/workspace/list"
+	wsmemberdelete "url-shortener/internal/http-server/handlers/workspace/member/delete"
+	wsmembersave "url-shortener/internal/http-server/handlers/workspace/member/save"
+	wssave "url-shortener/internal/http-server/handlers/workspace/save"
+	"url-shortener/internal/http-server/middleware/auth"
+	"url-shortener/internal/lib/aliaspolicy"
+)
+
+// Prefix is the mount point of the management API, its first segment is reserved for aliases
+const Prefix = "/api/v1"
+
+//go:embed openapi.json
+var spec []byte
+
+// Storage is everything the management handlers read and write
+type Storage interface {
+	list.URLsProvider
+	save.URLSaver
+	delete.URLDeleter
+	stats.URLGetter
+	stats.ClickCounter
+	utmsave.UTMTemplateSaver
+	utmlist.UTMTemplatesProvider
+	utmdelete.UTMTemplateDeleter
+	wssave.WorkspaceSaver
+	wslist.WorkspacesProvider
+	wsmembersave.MemberSaver
+	wsmemberdelete.MemberDeleter
+	apikeysave.APIKeySaver
+	apikeylist.APIKeysProvider
+	apikeydelete.APIKeyDeleter
+}
+
+// Routes registers the management endpoints, the router is expected to be mounted at Prefix.
+// The login endpoint is registered only with the authenticator of the local auth mode
+func Routes(
+	log *slog.Logger,
+	storage Storage,
+	cfg *config.Config,
+	policy *aliaspolicy.Policy,
+	authenticator login.Authenticator,
+) func(r chi.Router) {
+	return func(r chi.Router) {
+		r.Get("/openapi.json", OpenAPI)
+
+		if authenticator != nil {
+			r.Post("/auth/login", login.New(log, authenticator))
+		}
+
+		r.With(auth.RequireScope(log, auth.ScopeLinksRead)).Get("/links", list.New(log, storage))
+		r.With(auth.RequireScope(log, auth.ScopeLinksWrite)).Post("/links", save.New(log, storage, cfg, policy))
+		r.With(auth.RequireScope(log, auth.ScopeLinksDelete)).Delete("/links/{alias}", delete.New(log, storage, cfg.Domains))
+		r.With(auth.RequireScope(log, auth.ScopeAnalyticsRead)).Get("/links/{alias}/stats", stats.New(log, storage, storage, cfg.Domains))
+
+		r.With(auth.RequireScope(log, auth.ScopeLinksRead)).Get("/workspaces", wslist.New(log, storage))
+
+		r.Group(func(r chi.Router) {
+			r.Use(auth.RequireScope(log, auth.ScopeKeysManage))
+
+			r.Post("/api-keys", apikeysave.New(log, storage))
+			r.Get("/api-keys", apikeylist.New(log, storage))
+			r.Delete("/api-keys/{id}", apikeydelete.New(log, storage))
+		})
+
+		r.Group(func(r chi.Router) {
+			r.Use(auth.RequireScope(log, auth.ScopeAdmin))
+
+			r.Post("/utm-templates", utmsave.New(log, storage))
+			r.Get("/utm-templates", utmlist.New(log, storage))
+			r.Delete("/utm-templates/{name}", utmdelete.New(log, storage))
+
+			r.Post("/workspaces", wssave.New(log, storage))
+			r.Post("/workspaces/{name}/members", wsmembersave.New(log, storage))
+			r.Delete("/workspaces/{name}/members/{uid}", wsmemberdelete.New(log, storage))
+		})
+	}
+}
+
+// OpenAPI serves the OpenAPI 3 document of the management API
+func OpenAPI(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(spec)
+}
@@ -0,0 +1,182 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/config"
+	"url-shortener/internal/http-server/api"
+	"url-shortener/internal/http-server/middleware/auth"
+	"url-shortener/internal/lib/aliaspolicy"
+	"url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+)
+
+type operation struct {
+	OperationID string                     `json:"operationId"`
+	Security    *[]map[string][]string     `json:"security"`
+	Scopes      []string                   `json:"x-scopes"`
+	Responses   map[string]json.RawMessage `json:"responses"`
+}
+
+type document struct {
+	OpenAPI string `json:"openapi"`
+	Servers []struct {
+		URL string `json:"url"`
+	} `json:"servers"`
+	Paths map[string]map[string]operation `json:"paths"`
+}
+
+// authenticator enables the login endpoint of the local auth mode
+type authenticator struct{}
+
+func (authenticator) Login(context.Context, string, string) (string, time.Time, error) {
+	return "", time.Time{}, nil
+}
+
+func newRouter() chi.Router {
+	router := chi.NewRouter()
+	// handlers are not reached by the requests of the tests, so they need no storage
+	router.Route(api.Prefix, api.Routes(slogdiscard.NewDiscardLogger(), nil, &config.Config{}, nil, authenticator{}))
+	return router
+}
+
+func loadDocument(t *testing.T, router http.Handler) (document, []byte) {
+	t.Helper()
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, api.Prefix+"/openapi.json", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+
+	var doc document
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &doc))
+
+	return doc, rr.Body.Bytes()
+}
+
+func TestOpenAPI_Document(t *testing.T) {
+	doc, raw := loadDocument(t, newRouter())
+
+	assert.True(t, strings.HasPrefix(doc.OpenAPI, "3."))
+	require.Len(t, doc.Servers, 1)
+	assert.Equal(t, api.Prefix, doc.Servers[0].URL)
+
+	ids := map[string]bool{}
+	for path, ops := range doc.Paths {
+		for method, op := range ops {
+			assert.NotEmpty(t, op.OperationID, "%s %s", method, path)
+			assert.False(t, ids[op.OperationID], "duplicate operation id %s", op.OperationID)
+			ids[op.OperationID] = true
+			assert.Contains(t, op.Responses, "200", "%s %s", method, path)
+		}
+	}
+
+	// every reference points to a defined component
+	var tree any
+	require.NoError(t, json.Unmarshal(raw, &tree))
+	for _, ref := range refs(tree) {
+		target := tree
+		for _, key := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+			obj, ok := target.(map[string]any)
+			require.True(t, ok, "unresolved %s", ref)
+			target, ok = obj[key]
+			require.True(t, ok, "unresolved %s", ref)
+		}
+	}
+}
+
+func TestOpenAPI_Routes(t *testing.T) {
+	router := newRouter()
+
+	var routes []string
+	err := chi.Walk(router, func(method string, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		routes = append(routes, method+" "+strings.TrimPrefix(route, api.Prefix))
+		return nil
+	})
+	require.NoError(t, err)
+
+	doc, _ := loadDocument(t, router)
+	var documented []string
+	for path, ops := range doc.Paths {
+		for method := range ops {
+			documented = append(documented, strings.ToUpper(method)+" "+path)
+		}
+	}
+
+	sort.Strings(routes)
+	sort.Strings(documented)
+	assert.Equal(t, routes, documented)
+}
+
+func TestOpenAPI_Responses(t *testing.T) {
+	router := newRouter()
+	doc, _ := loadDocument(t, router)
+
+	users := []struct {
+		name string
+		ctx  context.Context
+		want int
+	}{
+		{name: "anonymous", ctx: context.Background(), want: http.StatusUnauthorized},
+		{name: "no scopes", ctx: auth.WithUser(context.Background(), auth.User{UID: 1}), want: http.StatusForbidden},
+	}
+
+	for path, ops := range doc.Paths {
+		for method, op := range ops {
+			if op.Security != nil && len(*op.Security) == 0 {
+				continue
+			}
+			require.NotEmpty(t, op.Scopes, "%s %s", method, path)
+
+			target := api.Prefix + strings.NewReplacer("{alias}", "abc123", "{name}", "marketing", "{uid}", "42", "{id}", "1").Replace(path)
+			for _, user := range users {
+				t.Run(method+" "+path+" "+user.name, func(t *testing.T) {
+					req := httptest.NewRequest(strings.ToUpper(method), target, nil).WithContext(user.ctx)
+					rr := httptest.NewRecorder()
+					router.ServeHTTP(rr, req)
+
+					require.Equal(t, user.want, rr.Code)
+					assert.Contains(t, op.Responses, strconv.Itoa(rr.Code))
+					assert.Equal(t, response.ContentTypeProblem, rr.Header().Get("Content-Type"))
+				})
+			}
+		}
+	}
+}
+
+func TestPrefixIsReserved(t *testing.T) {
+	segment := strings.Split(strings.TrimPrefix(api.Prefix, "/"), "/")[0]
+	assert.True(t, aliaspolicy.IsReserved(segment))
+}
+
+// refs collects values of all $ref keys of the document
+func refs(node any) []string {
+	var found []string
+	switch v := node.(type) {
+	case map[string]any:
+		for key, value := range v {
+			if s, ok := value.(string); ok && key == "$ref" {
+				found = append(found, s)
+				continue
+			}
+			found = append(found, refs(value)...)
+		}
+	case []any:
+		for _, value := range v {
+			found = append(found, refs(value)...)
+		}
+	}
+	return found
+}
@@ -0,0 +1,68 @@
+package delete
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+
+	"url-shortener/internal/http-server/middleware/auth"
+	"url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/storage"
+)
+
+type APIKeyDeleter interface {
+	DeleteAPIKey(id, uid int64) error
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=APIKeyDeleter
+func New(log *slog.Logger, keyDeleter APIKeyDeleter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.apikey.delete.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		user, err := auth.CurrentUser(r.Context())
+		if err != nil {
+			log.Error("failed to get user", sl.Err(err))
+			response.Fail(w, r, response.ErrInternal)
+			return
+		}
+
+		id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+		if err != nil || id <= 0 {
+			log.Error("invalid api key id", slog.String("id", chi.URLParam(r, "id")))
+			response.Fail(w, r, response.ErrInvalidRequest)
+			return
+		}
+
+		// admins revoke keys of any user, users only own keys
+		uid := user.UID
+		if user.IsAdmin {
+			uid = 0
+		}
+
+		err = keyDeleter.DeleteAPIKey(id, uid)
+		if err != nil {
+			if errors.Is(err, storage.ErrAPIKeyNotFound) {
+				log.Info("api key not found", slog.Int64("id", id))
+				response.Fail(w, r, response.NotFound("api_key_not_found", "api key not found"))
+			} else {
+				log.Error("failed to delete api key", sl.Err(err))
+				response.Fail(w, r, response.ErrInternal)
+			}
+			return
+		}
+
+		log.Info("api key revoked", slog.Int64("id", id))
+		render.JSON(w, r, response.OK())
+	}
+}
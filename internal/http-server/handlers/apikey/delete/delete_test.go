@@ -0,0 +1,99 @@
+package delete_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/handlers/apikey/delete"
+	"url-shortener/internal/http-server/handlers/apikey/delete/mocks"
+	"url-shortener/internal/http-server/middleware/auth"
+	"url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/storage"
+)
+
+func TestNew(t *testing.T) {
+	cases := []struct {
+		name      string
+		id        string
+		isAdmin   bool
+		uid       int64
+		respError string
+		status    int
+		mockError error
+	}{
+		{
+			name: "Own key",
+			id:   "5",
+			uid:  1,
+		},
+		{
+			name:    "Admin revokes any key",
+			id:      "5",
+			isAdmin: true,
+			uid:     0,
+		},
+		{
+			name:      "Not found",
+			id:        "5",
+			uid:       1,
+			respError: "api key not found",
+			status:    http.StatusNotFound,
+			mockError: storage.ErrAPIKeyNotFound,
+		},
+		{
+			name:      "Invalid id",
+			id:        "ci",
+			respError: "invalid request",
+			status:    http.StatusBadRequest,
+		},
+		{
+			name:      "DeleteAPIKey Error",
+			id:        "5",
+			uid:       1,
+			respError: "internal error",
+			status:    http.StatusInternalServerError,
+			mockError: errors.New("unexpected error"),
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			keyDeleterMock := mocks.NewAPIKeyDeleter(t)
+			if tc.status != http.StatusBadRequest {
+				keyDeleterMock.On("DeleteAPIKey", int64(5), tc.uid).
+					Return(tc.mockError).
+					Once()
+			}
+
+			r := chi.NewRouter()
+			r.Delete("/api-keys/{id}", delete.New(slogdiscard.NewDiscardLogger(), keyDeleterMock))
+
+			req, err := http.NewRequest(http.MethodDelete, "/api-keys/"+tc.id, nil)
+			require.NoError(t, err)
+			req = req.WithContext(auth.WithUser(req.Context(), auth.User{UID: 1, IsAdmin: tc.isAdmin}))
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+			if tc.respError != "" {
+				require.Equal(t, tc.status, rr.Code)
+				require.Equal(t, response.ContentTypeProblem, rr.Header().Get("Content-Type"))
+
+				var problem response.Problem
+				require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &problem))
+				require.Equal(t, tc.respError, problem.Detail)
+				return
+			}
+			require.Equal(t, http.StatusOK, rr.Code)
+
+			var resp response.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		})
+	}
+}
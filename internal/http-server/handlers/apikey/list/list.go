@@ -0,0 +1,84 @@
+package list
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+
+	"url-shortener/domain/models"
+	"url-shortener/internal/http-server/middleware/auth"
+	"url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/sl"
+)
+
+// APIKey describes a key without its value, the prefix tells keys apart
+type APIKey struct {
+	ID         int64      `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+type Response struct {
+	response.Response
+	APIKeys []APIKey `json:"api_keys"`
+}
+
+type APIKeysProvider interface {
+	APIKeys(uid int64) ([]models.APIKey, error)
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=APIKeysProvider
+func New(log *slog.Logger, keysProvider APIKeysProvider) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.apikey.list.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		user, err := auth.CurrentUser(r.Context())
+		if err != nil {
+			log.Error("failed to get user", sl.Err(err))
+			response.Fail(w, r, response.ErrInternal)
+			return
+		}
+
+		keys, err := keysProvider.APIKeys(user.UID)
+		if err != nil {
+			log.Error("failed to get api keys", sl.Err(err))
+			response.Fail(w, r, response.Internal("internal_error", "failed to get api keys"))
+			return
+		}
+
+		resp := Response{Response: response.OK(), APIKeys: make([]APIKey, 0, len(keys))}
+		for _, key := range keys {
+			resp.APIKeys = append(resp.APIKeys, APIKey{
+				ID:         key.ID,
+				Name:       key.Name,
+				Prefix:     key.Prefix,
+				Scopes:     key.Scopes,
+				ExpiresAt:  optionalTime(key.ExpiresAt),
+				LastUsedAt: optionalTime(key.LastUsedAt),
+				CreatedAt:  key.CreatedAt,
+			})
+		}
+
+		log.Info("api keys listed", slog.Int("count", len(resp.APIKeys)))
+		render.JSON(w, r, resp)
+	}
+}
+
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
@@ -0,0 +1,93 @@
+package list_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/domain/models"
+	"url-shortener/internal/http-server/handlers/apikey/list"
+	"url-shortener/internal/http-server/handlers/apikey/list/mocks"
+	"url-shortener/internal/http-server/middleware/auth"
+	"url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+)
+
+func TestNew(t *testing.T) {
+	createdAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	usedAt := createdAt.Add(time.Hour)
+
+	cases := []struct {
+		name      string
+		keys      []models.APIKey
+		respError string
+		status    int
+		mockError error
+	}{
+		{
+			name: "Success",
+			keys: []models.APIKey{
+				{ID: 1, Name: "ci", Prefix: "usk_abcdef", Hash: []byte("hash"), Scopes: []string{"links:write"},
+					LastUsedAt: usedAt, CreatedAt: createdAt},
+				{ID: 2, Name: "deploy", Prefix: "usk_ghijkl", Hash: []byte("hash"), Scopes: []string{"links:read"},
+					ExpiresAt: usedAt, CreatedAt: createdAt},
+			},
+		},
+		{
+			name: "No keys",
+			keys: []models.APIKey{},
+		},
+		{
+			name:      "APIKeys Error",
+			respError: "failed to get api keys",
+			status:    http.StatusInternalServerError,
+			mockError: errors.New("unexpected error"),
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			keysProviderMock := mocks.NewAPIKeysProvider(t)
+			keysProviderMock.On("APIKeys", int64(1)).
+				Return(tc.keys, tc.mockError).
+				Once()
+
+			handler := list.New(slogdiscard.NewDiscardLogger(), keysProviderMock)
+
+			req, err := http.NewRequest(http.MethodGet, "/api-keys", nil)
+			require.NoError(t, err)
+			req = req.WithContext(auth.WithUser(req.Context(), auth.User{UID: 1}))
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+			if tc.respError != "" {
+				require.Equal(t, tc.status, rr.Code)
+				require.Equal(t, response.ContentTypeProblem, rr.Header().Get("Content-Type"))
+
+				var problem response.Problem
+				require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &problem))
+				require.Equal(t, tc.respError, problem.Detail)
+				return
+			}
+			require.Equal(t, http.StatusOK, rr.Code)
+			require.NotContains(t, rr.Body.String(), "hash")
+
+			var resp list.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			require.NotNil(t, resp.APIKeys)
+			require.Len(t, resp.APIKeys, len(tc.keys))
+			for i, key := range tc.keys {
+				require.Equal(t, key.Name, resp.APIKeys[i].Name)
+				require.Equal(t, key.Prefix, resp.APIKeys[i].Prefix)
+				require.Equal(t, key.Scopes, resp.APIKeys[i].Scopes)
+				require.Equal(t, key.LastUsedAt.IsZero(), resp.APIKeys[i].LastUsedAt == nil)
+				require.Equal(t, key.ExpiresAt.IsZero(), resp.APIKeys[i].ExpiresAt == nil)
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package save
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+
+	"url-shortener/domain/models"
+	"url-shortener/internal/http-server/middleware/auth"
+	"url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/apikey"
+	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/storage"
+)
+
+type Request struct {
+	Name   string   `json:"name" validate:"required,max=64"`
+	Scopes []string `json:"scopes" validate:"required,min=1"`
+	// ExpiresAt is empty for keys that never expire
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+type Response struct {
+	response.Response
+	ID int64 `json:"id"`
+	// Key is returned only once, it can't be recovered later
+	Key       string     `json:"key"`
+	Prefix    string     `json:"prefix"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+type APIKeySaver interface {
+	SaveAPIKey(key models.APIKey) (int64, error)
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=APIKeySaver
+func New(log *slog.Logger, keySaver APIKeySaver) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.apikey.save.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		user, err := auth.CurrentUser(r.Context())
+		if err != nil {
+			log.Error("failed to get user", sl.Err(err))
+			response.Fail(w, r, response.ErrInternal)
+			return
+		}
+
+		var req Request
+		err = render.DecodeJSON(r.Body, &req)
+		if err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+			response.Fail(w, r, response.ErrDecodeRequest)
+			return
+		}
+
+		log.Info("request body decoded", slog.Any("request", req))
+
+		if err = validator.New().Struct(req); err != nil {
+			log.Error("invalid request", sl.Err(err))
+			response.FailValidation(w, r, err.(validator.ValidationErrors))
+			return
+		}
+
+		if apiErr := checkScopes(user, req.Scopes); apiErr != nil {
+			log.Info("scopes can't be granted", slog.Any("scopes", req.Scopes), slog.String("reason", apiErr.Code))
+			response.Fail(w, r, apiErr)
+			return
+		}
+
+		if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+			log.Info("expiration is in the past", slog.Time("expires_at", *req.ExpiresAt))
+			response.Fail(w, r, response.Unprocessable("invalid_expiration", "expiration must be in the future"))
+			return
+		}
+
+		raw, prefix, err := apikey.Generate()
+		if err != nil {
+			log.Error("failed to generate api key", sl.Err(err))
+			response.Fail(w, r, response.ErrInternal)
+			return
+		}
+
+		key := models.APIKey{
+			UID:    user.UID,
+			Email:  user.Email,
+			Name:   req.Name,
+			Prefix: prefix,
+			Hash:   apikey.Hash(raw),
+			Scopes: req.Scopes,
+		}
+		if req.ExpiresAt != nil {
+			key.ExpiresAt = *req.ExpiresAt
+		}
+
+		id, err := keySaver.SaveAPIKey(key)
+		if err != nil {
+			if errors.Is(err, storage.ErrAPIKeyExists) {
+				log.Info("api key already exists", slog.String("name", req.Name))
+				response.Fail(w, r, response.Conflict("api_key_exists", "api key with this name exists"))
+			} else {
+				log.Error("failed to add api key", sl.Err(err))
+				response.Fail(w, r, response.Internal("internal_error", "failed to add api key"))
+			}
+			return
+		}
+
+		log.Info("api key added", slog.Int64("id", id), slog.String("prefix", prefix))
+		render.JSON(w, r, Response{
+			Response:  response.OK(),
+			ID:        id,
+			Key:       raw,
+			Prefix:    prefix,
+			ExpiresAt: req.ExpiresAt,
+		})
+	}
+}
+
+// checkScopes allows only known scopes the user has, so a key never grants more than its owner.
+// Managing keys is not granted to keys at all
+func checkScopes(user auth.User, names []string) *response.APIError {
+	scopes := auth.ParseScopes(names)
+	if len(scopes) != len(names) {
+		return response.Unprocessable("unknown_scope", "unknown scope")
+	}
+
+	for _, scope := range scopes {
+		if scope == auth.ScopeKeysManage {
+			return response.Unprocessable("scope_not_allowed", "keys:manage can't be granted to api keys")
+		}
+		if !user.HasScope(scope) {
+			return response.Forbidden("scope_not_granted", "can't grant scope the user doesn't have")
+		}
+	}
+
+	return nil
+}
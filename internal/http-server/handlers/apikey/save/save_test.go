@@ -0,0 +1,176 @@
+package save_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/domain/models"
+	"url-shortener/internal/http-server/handlers/apikey/save"
+	"url-shortener/internal/http-server/handlers/apikey/save/mocks"
+	"url-shortener/internal/http-server/middleware/auth"
+	"url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/apikey"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/storage"
+)
+
+func TestNew(t *testing.T) {
+	future := time.Now().Add(24 * time.Hour).UTC().Format(time.RFC3339)
+	past := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+
+	cases := []struct {
+		name       string
+		input      string
+		isAdmin    bool
+		userScopes []auth.Scope
+		save       bool
+		scopes     []string
+		respError  string
+		status     int
+		mockError  error
+	}{
+		{
+			name:   "Success",
+			input:  `{"name": "ci", "scopes": ["links:write", "links:read"]}`,
+			save:   true,
+			scopes: []string{"links:write", "links:read"},
+		},
+		{
+			name:   "With expiration",
+			input:  `{"name": "ci", "scopes": ["links:write"], "expires_at": "` + future + `"}`,
+			save:   true,
+			scopes: []string{"links:write"},
+		},
+		{
+			name:    "Admin grants admin",
+			input:   `{"name": "ops", "scopes": ["admin"]}`,
+			isAdmin: true,
+			save:    true,
+			scopes:  []string{"admin"},
+		},
+		{
+			name:      "Empty scopes",
+			input:     `{"name": "ci", "scopes": []}`,
+			respError: "field Scopes is not valid",
+			status:    http.StatusUnprocessableEntity,
+		},
+		{
+			name:      "Empty name",
+			input:     `{"scopes": ["links:write"]}`,
+			respError: "field Name is a required field",
+			status:    http.StatusUnprocessableEntity,
+		},
+		{
+			name:      "Unknown scope",
+			input:     `{"name": "ci", "scopes": ["links:everything"]}`,
+			respError: "unknown scope",
+			status:    http.StatusUnprocessableEntity,
+		},
+		{
+			name:       "Scope the user doesn't have",
+			input:      `{"name": "ci", "scopes": ["analytics:read"]}`,
+			userScopes: []auth.Scope{auth.ScopeLinksRead, auth.ScopeKeysManage},
+			respError:  "can't grant scope the user doesn't have",
+			status:     http.StatusForbidden,
+		},
+		{
+			name:      "Admin scope by user",
+			input:     `{"name": "ci", "scopes": ["admin"]}`,
+			respError: "can't grant scope the user doesn't have",
+			status:    http.StatusForbidden,
+		},
+		{
+			name:      "Keys manage scope",
+			input:     `{"name": "ci", "scopes": ["keys:manage"]}`,
+			isAdmin:   true,
+			respError: "keys:manage can't be granted to api keys",
+			status:    http.StatusUnprocessableEntity,
+		},
+		{
+			name:      "Expiration in the past",
+			input:     `{"name": "ci", "scopes": ["links:write"], "expires_at": "` + past + `"}`,
+			respError: "expiration must be in the future",
+			status:    http.StatusUnprocessableEntity,
+		},
+		{
+			name:      "Already exists",
+			input:     `{"name": "ci", "scopes": ["links:write"]}`,
+			save:      true,
+			scopes:    []string{"links:write"},
+			respError: "api key with this name exists",
+			status:    http.StatusConflict,
+			mockError: storage.ErrAPIKeyExists,
+		},
+		{
+			name:      "SaveAPIKey Error",
+			input:     `{"name": "ci", "scopes": ["links:write"]}`,
+			save:      true,
+			scopes:    []string{"links:write"},
+			respError: "failed to add api key",
+			status:    http.StatusInternalServerError,
+			mockError: errors.New("unexpected error"),
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var saved models.APIKey
+			keySaverMock := mocks.NewAPIKeySaver(t)
+			if tc.save {
+				keySaverMock.On("SaveAPIKey", mock.MatchedBy(func(key models.APIKey) bool {
+					saved = key
+					return key.UID == 1 && key.Email == "user@example.com" && len(key.Hash) > 0
+				})).
+					Return(int64(5), tc.mockError).
+					Once()
+			}
+
+			handler := save.New(slogdiscard.NewDiscardLogger(), keySaverMock)
+
+			req, err := http.NewRequest(http.MethodPost, "/api-keys", bytes.NewReader([]byte(tc.input)))
+			require.NoError(t, err)
+			user := auth.User{UID: 1, Email: "user@example.com", Scopes: auth.DefaultScopes, IsAdmin: tc.isAdmin}
+			if tc.isAdmin {
+				user.Scopes = append(append([]auth.Scope{}, auth.DefaultScopes...), auth.ScopeAdmin)
+			}
+			if tc.userScopes != nil {
+				user.Scopes = tc.userScopes
+			}
+			req = req.WithContext(auth.WithUser(req.Context(), user))
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+			if tc.respError != "" {
+				require.Equal(t, tc.status, rr.Code)
+				require.Equal(t, response.ContentTypeProblem, rr.Header().Get("Content-Type"))
+
+				var problem response.Problem
+				require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &problem))
+				require.Equal(t, tc.respError, problem.Detail)
+				return
+			}
+			require.Equal(t, http.StatusOK, rr.Code)
+
+			var resp save.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			require.Equal(t, int64(5), resp.ID)
+			require.True(t, apikey.IsKey(resp.Key))
+			require.Equal(t, apikey.Hash(resp.Key), saved.Hash, "only the hash of the key is stored")
+			require.Equal(t, resp.Prefix, saved.Prefix)
+			require.Equal(t, tc.scopes, saved.Scopes)
+			if resp.ExpiresAt != nil {
+				require.True(t, resp.ExpiresAt.Equal(saved.ExpiresAt))
+			} else {
+				require.True(t, saved.ExpiresAt.IsZero())
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package login
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+
+	"url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/localauth"
+)
+
+type Request struct {
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password" validate:"required,max=72"`
+}
+
+type Response struct {
+	response.Response
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type Authenticator interface {
+	Login(ctx context.Context, email, password string) (string, time.Time, error)
+}
+
+var ErrInvalidCredentials = response.Unauthorized("invalid_credentials", "invalid email or password")
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=Authenticator
+func New(log *slog.Logger, authenticator Authenticator) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.auth.login.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		var req Request
+		err := render.DecodeJSON(r.Body, &req)
+		if err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+			response.Fail(w, r, response.ErrDecodeRequest)
+			return
+		}
+
+		// the password is never logged
+		log.Info("request body decoded", slog.String("email", req.Email))
+
+		if err = validator.New().Struct(req); err != nil {
+			log.Error("invalid request", sl.Err(err))
+			response.FailValidation(w, r, err.(validator.ValidationErrors))
+			return
+		}
+
+		token, expiresAt, err := authenticator.Login(r.Context(), req.Email, req.Password)
+		if err != nil {
+			if errors.Is(err, localauth.ErrInvalidCredentials) {
+				log.Info("invalid credentials", slog.String("email", req.Email))
+				response.Fail(w, r, ErrInvalidCredentials)
+			} else {
+				log.Error("failed to login", sl.Err(err))
+				response.Fail(w, r, response.Internal("internal_error", "failed to login"))
+			}
+			return
+		}
+
+		log.Info("user logged in", slog.String("email", req.Email))
+		render.JSON(w, r, Response{
+			Response:  response.OK(),
+			Token:     token,
+			ExpiresAt: expiresAt,
+		})
+	}
+}
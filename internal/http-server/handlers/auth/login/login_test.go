@@ -0,0 +1,114 @@
+package login_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/handlers/auth/login"
+	"url-shortener/internal/http-server/handlers/auth/login/mocks"
+	"url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/localauth"
+)
+
+func TestNew(t *testing.T) {
+	expiresAt := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		name      string
+		input     string
+		email     string
+		password  string
+		token     string
+		respError string
+		status    int
+		mockError error
+	}{
+		{
+			name:     "Success",
+			input:    `{"email": "user@example.com", "password": "correct horse"}`,
+			email:    "user@example.com",
+			password: "correct horse",
+			token:    "signed.jwt.token",
+		},
+		{
+			name:      "Empty password",
+			input:     `{"email": "user@example.com"}`,
+			respError: "field Password is a required field",
+			status:    http.StatusUnprocessableEntity,
+		},
+		{
+			name:      "Invalid email",
+			input:     `{"email": "user", "password": "correct horse"}`,
+			respError: "field Email is not valid",
+			status:    http.StatusUnprocessableEntity,
+		},
+		{
+			name:      "Malformed body",
+			input:     `{"email": `,
+			respError: "failed to decode request",
+			status:    http.StatusBadRequest,
+		},
+		{
+			name:      "Invalid credentials",
+			input:     `{"email": "user@example.com", "password": "wrong horse"}`,
+			email:     "user@example.com",
+			password:  "wrong horse",
+			respError: "invalid email or password",
+			status:    http.StatusUnauthorized,
+			mockError: fmt.Errorf("localauth.Login: %w", localauth.ErrInvalidCredentials),
+		},
+		{
+			name:      "Login Error",
+			input:     `{"email": "user@example.com", "password": "correct horse"}`,
+			email:     "user@example.com",
+			password:  "correct horse",
+			respError: "failed to login",
+			status:    http.StatusInternalServerError,
+			mockError: errors.New("unexpected error"),
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			authMock := mocks.NewAuthenticator(t)
+			if tc.email != "" {
+				authMock.On("Login", mock.Anything, tc.email, tc.password).
+					Return(tc.token, expiresAt, tc.mockError).
+					Once()
+			}
+
+			handler := login.New(slogdiscard.NewDiscardLogger(), authMock)
+
+			req, err := http.NewRequest(http.MethodPost, "/auth/login", bytes.NewReader([]byte(tc.input)))
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+			if tc.respError != "" {
+				require.Equal(t, tc.status, rr.Code)
+				require.Equal(t, response.ContentTypeProblem, rr.Header().Get("Content-Type"))
+
+				var problem response.Problem
+				require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &problem))
+				require.Equal(t, tc.respError, problem.Detail)
+				return
+			}
+			require.Equal(t, http.StatusOK, rr.Code)
+
+			var resp login.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			require.Equal(t, tc.token, resp.Token)
+			require.True(t, expiresAt.Equal(resp.ExpiresAt))
+		})
+	}
+}
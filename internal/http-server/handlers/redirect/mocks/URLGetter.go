@@ -2,30 +2,34 @@
 
 package mocks
 
-import mock "github.com/stretchr/testify/mock"
+import (
+	mock "github.com/stretchr/testify/mock"
+
+	models "url-shortener/domain/models"
+)
 
 // URLGetter is an autogenerated mock type for the URLGetter type
 type URLGetter struct {
 	mock.Mock
 }
 
-// GetURL provides a mock function with given fields: alias
-func (_m *URLGetter) GetURL(alias string) (string, error) {
-	ret := _m.Called(alias)
+// URL provides a mock function with given fields: domain, alias
+func (_m *URLGetter) URL(domain string, alias string) (models.URL, error) {
+	ret := _m.Called(domain, alias)
 
-	var r0 string
+	var r0 models.URL
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string) (string, error)); ok {
-		return rf(alias)
+	if rf, ok := ret.Get(0).(func(string, string) (models.URL, error)); ok {
+		return rf(domain, alias)
 	}
-	if rf, ok := ret.Get(0).(func(string) string); ok {
-		r0 = rf(alias)
+	if rf, ok := ret.Get(0).(func(string, string) models.URL); ok {
+		r0 = rf(domain, alias)
 	} else {
-		r0 = ret.Get(0).(string)
+		r0 = ret.Get(0).(models.URL)
 	}
 
-	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(alias)
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(domain, alias)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -0,0 +1,89 @@
+package redirect
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"html/template"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"url-shortener/domain/models"
+)
+
+//go:embed templates/*.html
+var templates embed.FS
+
+var previewTmpl = template.Must(template.ParseFS(templates, "templates/preview.html"))
+
+const (
+	safetySafe    = "safe"
+	safetyWarning = "warning"
+)
+
+type previewPage struct {
+	Alias     string
+	URL       string
+	Host      string
+	CreatedAt string
+	Safety    string
+	Notes     []string
+}
+
+func renderPreview(w http.ResponseWriter, url models.URL) error {
+	const op = "handlers.redirect.renderPreview"
+
+	page := previewPage{
+		Alias:     url.Alias,
+		URL:       url.URL,
+		CreatedAt: "unknown",
+	}
+	if !url.CreatedAt.IsZero() {
+		page.CreatedAt = url.CreatedAt.UTC().Format(time.RFC1123)
+	}
+	page.Host, page.Safety, page.Notes = safetyStatus(url.URL)
+
+	var buf bytes.Buffer
+	if err := previewTmpl.Execute(&buf, page); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("X-Robots-Tag", "noindex")
+	_, _ = buf.WriteTo(w)
+
+	return nil
+}
+
+// safetyStatus makes a basic offline assessment of the destination URL.
+// It returns the destination host, the overall status and the reasons of warnings
+func safetyStatus(rawURL string) (string, string, []string) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", safetyWarning, []string{"destination address could not be parsed"}
+	}
+
+	var notes []string
+	if u.Scheme != "https" {
+		notes = append(notes, "connection to the destination is not encrypted")
+	}
+	if u.User != nil {
+		notes = append(notes, "address contains credentials, the real host may differ from what it looks like")
+	}
+	if net.ParseIP(u.Hostname()) != nil {
+		notes = append(notes, "destination is an IP address instead of a domain name")
+	}
+	if strings.Contains(u.Hostname(), "xn--") {
+		notes = append(notes, "domain name contains international characters that may imitate another site")
+	}
+
+	if len(notes) > 0 {
+		return u.Hostname(), safetyWarning, notes
+	}
+
+	return u.Hostname(), safetySafe, nil
+}
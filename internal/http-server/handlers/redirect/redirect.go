@@ -2,53 +2,220 @@ package redirect
 
 import (
 	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
+	"url-shortener/domain/models"
+	"url-shortener/internal/http-server/middleware/domain"
 	"url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/destination"
 	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/lib/routing"
 	"url-shortener/internal/storage"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
-	"github.com/go-chi/render"
 )
 
+// previewSuffix appended to an alias shows the preview page instead of redirecting
+const previewSuffix = "+"
+
 type URLGetter interface {
-	GetURL(alias string) (string, error)
+	URL(domain, alias string) (models.URL, error)
+}
+
+type ClickSaver interface {
+	SaveClick(click models.Click) error
+}
+
+// CountryLocator resolves the visitor country, empty string means unknown location
+type CountryLocator interface {
+	Country(r *http.Request) string
 }
 
 //go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=URLGetter
-func New(log *slog.Logger, urlGetter URLGetter) http.HandlerFunc {
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=ClickSaver
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=CountryLocator
+func New(
+	log *slog.Logger,
+	urlGetter URLGetter,
+	clickSaver ClickSaver,
+	locator CountryLocator,
+) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.redirect.new"
 
-		log = log.With(
+		log := log.With(
 			slog.String("op", op),
 			slog.String("request_id", middleware.GetReqID(r.Context())),
 		)
 
 		alias := chi.URLParam(r, "alias")
+		alias, preview := isPreview(r, alias)
 		if alias == "" {
 			log.Error("alias is empty")
-			render.JSON(w, r, response.Error("invalid request"))
+			response.Fail(w, r, response.ErrInvalidRequest)
 			return
 		}
 
-		url, err := urlGetter.GetURL(alias)
+		url, err := urlGetter.URL(domain.FromContext(r.Context()), alias)
 		if err != nil {
 			if errors.Is(err, storage.ErrURLNotFound) {
 				log.Error("url not found", slog.String("alias", alias))
-				render.JSON(w, r, response.Error("url not found"))
+				response.Fail(w, r, response.NotFound("url_not_found", "url not found"))
 			} else {
 				log.Error("failed to get url", sl.Err(err))
-				render.JSON(w, r, response.Error("internal error"))
+				response.Fail(w, r, response.ErrInternal)
 			}
 			return
 		}
 
-		log.Info("got url", slog.String("url", url))
+		log.Info("got url", slog.String("url", url.URL))
+
+		if preview {
+			if err := renderPreview(w, url); err != nil {
+				log.Error("failed to render preview", sl.Err(err))
+			}
+			return
+		}
+
+		suffix := pathSuffix(r)
+		if suffix != "" && !url.Passthrough {
+			log.Error("url not found", slog.String("alias", alias), slog.String("suffix", suffix))
+			response.Fail(w, r, response.NotFound("url_not_found", "url not found"))
+			return
+		}
+
+		country := locator.Country(r)
+
+		base := url.URL
+		variant, hasVariant := models.Variant{}, false
+		if rule, ok := routing.Match(url.Rules, r, country, time.Now()); ok {
+			base = rule.URL
+		} else if variant, hasVariant = assignVariant(w, r, url); hasVariant {
+			base = variant.URL
+		}
+
+		dest, err := buildDestination(r, url, base, suffix)
+		if errors.Is(err, destination.ErrInvalidSuffix) {
+			log.Error("invalid path suffix", slog.String("alias", alias), slog.String("suffix", suffix))
+			response.Fail(w, r, response.ErrInvalidRequest)
+			return
+		}
+		if err != nil {
+			log.Error("failed to build destination", sl.Err(err))
+			response.Fail(w, r, response.ErrInternal)
+			return
+		}
+
+		if r.Method == http.MethodGet {
+			click := models.Click{
+				URLID:     url.ID,
+				VariantID: variant.ID,
+				Country:   country,
+				CreatedAt: time.Now(),
+			}
+			if err := clickSaver.SaveClick(click); err != nil {
+				log.Error("failed to save click", sl.Err(err))
+			}
+		}
 
-		http.Redirect(w, r, url, http.StatusFound)
+		shared := len(url.Rules) == 0 && len(url.Variants) == 0
+		if cacheControl := cacheControl(url.CacheMaxAge, shared); cacheControl != "" {
+			w.Header().Set("Cache-Control", cacheControl)
+		}
+		if vary := vary(url); vary != "" {
+			w.Header().Set("Vary", vary)
+		}
+
+		http.Redirect(w, r, dest, redirectCode(url.RedirectCode))
 	}
 }
+
+// buildDestination composes the final URL from the base one chosen by rules or variants,
+// UTM template parameters and, if passthrough is enabled, the request query and path suffix
+func buildDestination(r *http.Request, url models.URL, base string, suffix string) (string, error) {
+	var err error
+
+	dest := base
+	if url.UTMTemplate != nil {
+		dest, err = destination.WithParams(dest, url.UTMTemplate.Params())
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if url.Passthrough {
+		dest, err = destination.Passthrough(dest, r.URL.Query(), suffix, url.QueryConflict)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return dest, nil
+}
+
+// vary lists request headers the destination of the link depends on
+func vary(url models.URL) string {
+	var headers []string
+	if len(url.Rules) > 0 {
+		headers = append(headers, "User-Agent", "Accept-Language", "Referer")
+	}
+	if len(url.Variants) > 0 {
+		headers = append(headers, "Cookie")
+	}
+	return strings.Join(headers, ", ")
+}
+
+// pathSuffix returns the part of the request path following the alias
+func pathSuffix(r *http.Request) string {
+	if chi.URLParam(r, "*") == "" {
+		return ""
+	}
+
+	_, suffix, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+
+	return suffix
+}
+
+// redirectCode falls back to 302 Found if the stored code is not a redirect one
+func redirectCode(code int) int {
+	switch code {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther,
+		http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		return code
+	default:
+		return http.StatusFound
+	}
+}
+
+// cacheControl builds Cache-Control header value from the link max age in seconds.
+// Redirects depending on the visitor must not be stored by shared caches
+func cacheControl(maxAge int, shared bool) string {
+	switch {
+	case maxAge < 0:
+		return ""
+	case maxAge == 0:
+		return "no-store"
+	case shared:
+		return fmt.Sprintf("public, max-age=%d", maxAge)
+	default:
+		return fmt.Sprintf("private, max-age=%d", maxAge)
+	}
+}
+
+// isPreview strips the preview suffix from alias and reports
+// whether the preview page was requested by suffix or by query
+func isPreview(r *http.Request, alias string) (string, bool) {
+	if strings.HasSuffix(alias, previewSuffix) {
+		return strings.TrimSuffix(alias, previewSuffix), true
+	}
+
+	preview, _ := strconv.ParseBool(r.URL.Query().Get("preview"))
+
+	return alias, preview
+}
@@ -1,25 +1,51 @@
 package redirect_test
 
 import (
+	"io"
+	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"testing"
+	"time"
 
+	"url-shortener/domain/models"
 	"url-shortener/internal/http-server/handlers/redirect"
 	"url-shortener/internal/http-server/handlers/redirect/mocks"
+	"url-shortener/internal/http-server/middleware/domain"
 	"url-shortener/internal/lib/api"
+	"url-shortener/internal/lib/destination"
 	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/lib/routing"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 )
 
+func newClickSaver(t *testing.T) *mocks.ClickSaver {
+	clickSaverMock := mocks.NewClickSaver(t)
+	clickSaverMock.On("SaveClick", mock.AnythingOfType("models.Click")).
+		Return(nil).
+		Maybe()
+	return clickSaverMock
+}
+
+func newLocator(t *testing.T, country string) *mocks.CountryLocator {
+	locatorMock := mocks.NewCountryLocator(t)
+	locatorMock.On("Country", mock.Anything).
+		Return(country).
+		Maybe()
+	return locatorMock
+}
+
 func TestNew(t *testing.T) {
 	cases := []struct {
 		name      string
 		alias     string
 		url       string
 		respError string
+		status    int
 		mockError error
 	}{
 		{
@@ -35,14 +61,14 @@ func TestNew(t *testing.T) {
 
 			if tc.respError == "" || tc.mockError != nil {
 				if tc.alias != "" {
-					urlGetterMock.On("GetURL", tc.alias).
-						Return(tc.url, tc.mockError).
+					urlGetterMock.On("URL", "", tc.alias).
+						Return(models.URL{Alias: tc.alias, URL: tc.url}, tc.mockError).
 						Once()
 				}
 			}
 
 			r := chi.NewRouter()
-			r.Get("/{alias}", redirect.New(slogdiscard.NewDiscardLogger(), urlGetterMock))
+			r.Get("/{alias}", redirect.New(slogdiscard.NewDiscardLogger(), urlGetterMock, newClickSaver(t), newLocator(t, "")))
 
 			ts := httptest.NewServer(r)
 			defer ts.Close()
@@ -54,3 +80,477 @@ func TestNew(t *testing.T) {
 		})
 	}
 }
+
+func TestNew_Preview(t *testing.T) {
+	cases := []struct {
+		name     string
+		path     string
+		alias    string
+		url      string
+		contains []string
+	}{
+		{
+			name:     "Suffix",
+			path:     "/test_alias+",
+			alias:    "test_alias",
+			url:      "https://google.com",
+			contains: []string{"https://google.com", "safe", "02 Jan 2023"},
+		},
+		{
+			name:     "Query",
+			path:     "/test_alias?preview=1",
+			alias:    "test_alias",
+			url:      "http://192.168.0.1/login",
+			contains: []string{"http://192.168.0.1/login", "warning", "not encrypted", "IP address"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			urlGetterMock := mocks.NewURLGetter(t)
+			urlGetterMock.On("URL", "", tc.alias).
+				Return(models.URL{
+					Alias:     tc.alias,
+					URL:       tc.url,
+					CreatedAt: time.Date(2023, time.January, 2, 3, 4, 5, 0, time.UTC),
+				}, nil).
+				Once()
+
+			r := chi.NewRouter()
+			r.Get("/{alias}", redirect.New(slogdiscard.NewDiscardLogger(), urlGetterMock, newClickSaver(t), newLocator(t, "")))
+
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, http.StatusOK, rr.Code)
+			assert.Contains(t, rr.Header().Get("Content-Type"), "text/html")
+
+			body, err := io.ReadAll(rr.Body)
+			require.NoError(t, err)
+			for _, s := range tc.contains {
+				assert.Contains(t, string(body), s)
+			}
+		})
+	}
+}
+
+func TestNew_RedirectSettings(t *testing.T) {
+	cases := []struct {
+		name         string
+		method       string
+		redirectCode int
+		cacheMaxAge  int
+		wantCode     int
+		wantCache    string
+	}{
+		{
+			name:         "Permanent with cache",
+			method:       http.MethodGet,
+			redirectCode: http.StatusMovedPermanently,
+			cacheMaxAge:  3600,
+			wantCode:     http.StatusMovedPermanently,
+			wantCache:    "public, max-age=3600",
+		},
+		{
+			name:         "Temporary without cache",
+			method:       http.MethodGet,
+			redirectCode: http.StatusTemporaryRedirect,
+			cacheMaxAge:  0,
+			wantCode:     http.StatusTemporaryRedirect,
+			wantCache:    "no-store",
+		},
+		{
+			name:         "Invalid code falls back to found",
+			method:       http.MethodGet,
+			redirectCode: http.StatusOK,
+			cacheMaxAge:  -1,
+			wantCode:     http.StatusFound,
+		},
+		{
+			name:         "HEAD request",
+			method:       http.MethodHead,
+			redirectCode: http.StatusPermanentRedirect,
+			cacheMaxAge:  60,
+			wantCode:     http.StatusPermanentRedirect,
+			wantCache:    "public, max-age=60",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			urlGetterMock := mocks.NewURLGetter(t)
+			urlGetterMock.On("URL", "", "test_alias").
+				Return(models.URL{
+					Alias:        "test_alias",
+					URL:          "https://google.com",
+					RedirectCode: tc.redirectCode,
+					CacheMaxAge:  tc.cacheMaxAge,
+				}, nil).
+				Once()
+
+			handler := redirect.New(slogdiscard.NewDiscardLogger(), urlGetterMock, newClickSaver(t), newLocator(t, ""))
+			r := chi.NewRouter()
+			r.Get("/{alias}", handler)
+			r.Head("/{alias}", handler)
+
+			req := httptest.NewRequest(tc.method, "/test_alias", nil)
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			assert.Equal(t, tc.wantCode, rr.Code)
+			assert.Equal(t, "https://google.com", rr.Header().Get("Location"))
+			assert.Equal(t, tc.wantCache, rr.Header().Get("Cache-Control"))
+		})
+	}
+}
+
+func TestNew_Passthrough(t *testing.T) {
+	cases := []struct {
+		name        string
+		path        string
+		passthrough bool
+		conflict    string
+		wantCode    int
+		wantURL     string
+	}{
+		{
+			name:        "Query and path",
+			path:        "/test_alias/extra/path?ref=newsletter",
+			passthrough: true,
+			conflict:    destination.ConflictDestination,
+			wantCode:    http.StatusFound,
+			wantURL:     "https://google.com/search/extra/path?q=go&ref=newsletter",
+		},
+		{
+			name:        "Conflicting key from request",
+			path:        "/test_alias?q=rust",
+			passthrough: true,
+			conflict:    destination.ConflictRequest,
+			wantCode:    http.StatusFound,
+			wantURL:     "https://google.com/search?q=rust",
+		},
+		{
+			name:        "Parent segments in path",
+			path:        "/test_alias/docs/../../admin?x=1",
+			passthrough: true,
+			conflict:    destination.ConflictDestination,
+			wantCode:    http.StatusBadRequest,
+		},
+		{
+			name:        "Escaped parent segments in path",
+			path:        "/test_alias/%2e%2e/admin",
+			passthrough: true,
+			conflict:    destination.ConflictDestination,
+			wantCode:    http.StatusBadRequest,
+		},
+		{
+			name:     "Disabled passthrough drops query",
+			path:     "/test_alias?ref=newsletter",
+			wantCode: http.StatusFound,
+			wantURL:  "https://google.com/search?q=go",
+		},
+		{
+			name:     "Disabled passthrough rejects path",
+			path:     "/test_alias/extra",
+			wantCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			urlGetterMock := mocks.NewURLGetter(t)
+			urlGetterMock.On("URL", "", "test_alias").
+				Return(models.URL{
+					Alias:         "test_alias",
+					URL:           "https://google.com/search?q=go",
+					RedirectCode:  http.StatusFound,
+					Passthrough:   tc.passthrough,
+					QueryConflict: tc.conflict,
+				}, nil).
+				Once()
+
+			handler := redirect.New(slogdiscard.NewDiscardLogger(), urlGetterMock, newClickSaver(t), newLocator(t, ""))
+			r := chi.NewRouter()
+			r.Get("/{alias}", handler)
+			r.Get("/{alias}/*", handler)
+
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			assert.Equal(t, tc.wantCode, rr.Code)
+			assert.Equal(t, tc.wantURL, rr.Header().Get("Location"))
+		})
+	}
+}
+
+func TestNew_UTMTemplate(t *testing.T) {
+	urlGetterMock := mocks.NewURLGetter(t)
+	urlGetterMock.On("URL", "", "test_alias").
+		Return(models.URL{
+			Alias:         "test_alias",
+			URL:           "https://google.com/?utm_source=site",
+			RedirectCode:  http.StatusFound,
+			Passthrough:   true,
+			QueryConflict: destination.ConflictDestination,
+			UTMTemplate: &models.UTMTemplate{
+				Name:     "spring",
+				Source:   "newsletter",
+				Medium:   "email",
+				Campaign: "spring_sale",
+			},
+		}, nil).
+		Once()
+
+	r := chi.NewRouter()
+	r.Get("/{alias}", redirect.New(slogdiscard.NewDiscardLogger(), urlGetterMock, newClickSaver(t), newLocator(t, "")))
+
+	req := httptest.NewRequest(http.MethodGet, "/test_alias?utm_medium=social&ref=tw", nil)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusFound, rr.Code)
+	assert.Equal(t,
+		"https://google.com/?ref=tw&utm_campaign=spring_sale&utm_medium=email&utm_source=site",
+		rr.Header().Get("Location"),
+	)
+}
+
+func TestNew_Rules(t *testing.T) {
+	cases := []struct {
+		name      string
+		userAgent string
+		wantURL   string
+	}{
+		{
+			name:      "iOS",
+			userAgent: "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X)",
+			wantURL:   "https://apps.apple.com/app",
+		},
+		{
+			name:      "Android",
+			userAgent: "Mozilla/5.0 (Linux; Android 14; Pixel 8)",
+			wantURL:   "https://play.google.com/store/apps",
+		},
+		{
+			name:      "Fallback",
+			userAgent: "Mozilla/5.0 (Windows NT 10.0; Win64; x64)",
+			wantURL:   "https://example.com",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			urlGetterMock := mocks.NewURLGetter(t)
+			urlGetterMock.On("URL", "", "test_alias").
+				Return(models.URL{
+					Alias:        "test_alias",
+					URL:          "https://example.com",
+					RedirectCode: http.StatusFound,
+					Rules: []models.Rule{
+						{Platform: routing.PlatformIOS, URL: "https://apps.apple.com/app"},
+						{Platform: routing.PlatformAndroid, URL: "https://play.google.com/store/apps"},
+					},
+				}, nil).
+				Once()
+
+			r := chi.NewRouter()
+			r.Get("/{alias}", redirect.New(slogdiscard.NewDiscardLogger(), urlGetterMock, newClickSaver(t), newLocator(t, "")))
+
+			req := httptest.NewRequest(http.MethodGet, "/test_alias", nil)
+			req.Header.Set("User-Agent", tc.userAgent)
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusFound, rr.Code)
+			assert.Equal(t, tc.wantURL, rr.Header().Get("Location"))
+			assert.Contains(t, rr.Header().Get("Vary"), "User-Agent")
+		})
+	}
+}
+
+func TestNew_Variants(t *testing.T) {
+	variants := []models.Variant{
+		{ID: 1, URL: "https://a.example.com", Weight: 1},
+		{ID: 2, URL: "https://b.example.com", Weight: 1},
+	}
+
+	cases := []struct {
+		name       string
+		method     string
+		cookie     string
+		wantURL    string
+		wantCookie bool
+		wantCount  bool
+	}{
+		{
+			name:      "Sticky variant from cookie",
+			method:    http.MethodGet,
+			cookie:    "2",
+			wantURL:   "https://b.example.com",
+			wantCount: true,
+		},
+		{
+			name:       "Unknown variant in cookie",
+			method:     http.MethodGet,
+			cookie:     "42",
+			wantCookie: true,
+			wantCount:  true,
+		},
+		{
+			name:       "New visitor",
+			method:     http.MethodGet,
+			wantCookie: true,
+			wantCount:  true,
+		},
+		{
+			name:    "HEAD is not counted",
+			method:  http.MethodHead,
+			cookie:  "1",
+			wantURL: "https://a.example.com",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			urlGetterMock := mocks.NewURLGetter(t)
+			urlGetterMock.On("URL", "", "test_alias").
+				Return(models.URL{
+					Alias:        "test_alias",
+					URL:          "https://example.com",
+					RedirectCode: http.StatusFound,
+					Variants:     variants,
+				}, nil).
+				Once()
+
+			clickSaverMock := mocks.NewClickSaver(t)
+			if tc.wantCount {
+				clickSaverMock.On("SaveClick", mock.MatchedBy(func(click models.Click) bool {
+					return click.VariantID != 0
+				})).
+					Return(nil).
+					Once()
+			}
+
+			handler := redirect.New(slogdiscard.NewDiscardLogger(), urlGetterMock, clickSaverMock, newLocator(t, ""))
+			r := chi.NewRouter()
+			r.Get("/{alias}", handler)
+			r.Head("/{alias}", handler)
+
+			req := httptest.NewRequest(tc.method, "/test_alias", nil)
+			if tc.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: "variant", Value: tc.cookie})
+			}
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusFound, rr.Code)
+			location := rr.Header().Get("Location")
+			if tc.wantURL != "" {
+				assert.Equal(t, tc.wantURL, location)
+			} else {
+				assert.Contains(t, []string{"https://a.example.com", "https://b.example.com"}, location)
+			}
+
+			cookies := rr.Result().Cookies()
+			if !tc.wantCookie {
+				assert.Empty(t, cookies)
+				return
+			}
+			require.Len(t, cookies, 1)
+			assert.Equal(t, "variant", cookies[0].Name)
+			assert.Equal(t, "/test_alias", cookies[0].Path)
+			for _, variant := range variants {
+				if variant.URL == location {
+					assert.Equal(t, strconv.FormatInt(variant.ID, 10), cookies[0].Value)
+				}
+			}
+		})
+	}
+}
+
+func TestNew_Geo(t *testing.T) {
+	cases := []struct {
+		name    string
+		country string
+		wantURL string
+	}{
+		{
+			name:    "Matching country",
+			country: "DE",
+			wantURL: "https://example.de",
+		},
+		{
+			name:    "Other country",
+			country: "FR",
+			wantURL: "https://example.com",
+		},
+		{
+			name:    "Unknown location",
+			wantURL: "https://example.com",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			urlGetterMock := mocks.NewURLGetter(t)
+			urlGetterMock.On("URL", "", "test_alias").
+				Return(models.URL{
+					ID:           7,
+					Alias:        "test_alias",
+					URL:          "https://example.com",
+					RedirectCode: http.StatusFound,
+					CacheMaxAge:  60,
+					Rules:        []models.Rule{{Country: "DE", URL: "https://example.de"}},
+				}, nil).
+				Once()
+
+			clickSaverMock := mocks.NewClickSaver(t)
+			clickSaverMock.On("SaveClick", mock.MatchedBy(func(click models.Click) bool {
+				return click.URLID == 7 && click.Country == tc.country && click.VariantID == 0
+			})).
+				Return(nil).
+				Once()
+
+			r := chi.NewRouter()
+			r.Get("/{alias}", redirect.New(
+				slogdiscard.NewDiscardLogger(), urlGetterMock, clickSaverMock, newLocator(t, tc.country),
+			))
+
+			req := httptest.NewRequest(http.MethodGet, "/test_alias", nil)
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusFound, rr.Code)
+			assert.Equal(t, tc.wantURL, rr.Header().Get("Location"))
+			assert.Equal(t, "private, max-age=60", rr.Header().Get("Cache-Control"))
+		})
+	}
+}
+
+func TestNew_Domain(t *testing.T) {
+	urlGetterMock := mocks.NewURLGetter(t)
+	urlGetterMock.On("URL", "go.brand-a.com", "test_alias").
+		Return(models.URL{Alias: "test_alias", URL: "https://brand-a.com", RedirectCode: http.StatusFound}, nil).
+		Once()
+	urlGetterMock.On("URL", "", "test_alias").
+		Return(models.URL{Alias: "test_alias", URL: "https://example.com", RedirectCode: http.StatusFound}, nil).
+		Once()
+
+	r := chi.NewRouter()
+	r.Use(domain.New(slogdiscard.NewDiscardLogger(), []string{"go.brand-a.com"}))
+	r.Get("/{alias}", redirect.New(slogdiscard.NewDiscardLogger(), urlGetterMock, newClickSaver(t), newLocator(t, "")))
+
+	for host, want := range map[string]string{
+		"go.brand-a.com": "https://brand-a.com",
+		"localhost:8085": "https://example.com",
+	} {
+		req := httptest.NewRequest(http.MethodGet, "http://"+host+"/test_alias", nil)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusFound, rr.Code)
+		assert.Equal(t, want, rr.Header().Get("Location"))
+	}
+}
@@ -0,0 +1,44 @@
+package redirect
+
+import (
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"url-shortener/domain/models"
+	"url-shortener/internal/lib/rotation"
+)
+
+const (
+	// variantCookie keeps the variant assigned to the visitor, the cookie is scoped to the alias path
+	variantCookie = "variant"
+	// variantCookieMaxAge is 30 days in seconds
+	variantCookieMaxAge = 30 * 24 * 60 * 60
+)
+
+// assignVariant returns the variant previously assigned to the visitor or picks a new one
+// by weight and remembers it in the cookie
+func assignVariant(w http.ResponseWriter, r *http.Request, link models.URL) (models.Variant, bool) {
+	if cookie, err := r.Cookie(variantCookie); err == nil {
+		if variant, ok := rotation.Sticky(link.Variants, cookie.Value); ok {
+			return variant, true
+		}
+	}
+
+	variant, ok := rotation.Pick(link.Variants, rand.Intn)
+	if !ok {
+		return models.Variant{}, false
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     variantCookie,
+		Value:    strconv.FormatInt(variant.ID, 10),
+		Path:     "/" + url.PathEscape(link.Alias),
+		MaxAge:   variantCookieMaxAge,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	return variant, true
+}
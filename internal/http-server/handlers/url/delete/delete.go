@@ -5,7 +5,9 @@ import (
 	"log/slog"
 	"net/http"
 
+	"url-shortener/domain/models"
 	"url-shortener/internal/http-server/middleware/auth"
+	"url-shortener/internal/http-server/middleware/domain"
 	"url-shortener/internal/lib/api/response"
 	"url-shortener/internal/lib/logger/sl"
 	"url-shortener/internal/storage"
@@ -16,59 +18,91 @@ import (
 )
 
 type URLDeleter interface {
-	DeleteURL(alias string) error
+	URL(domain, alias string) (models.URL, error)
+	DeleteURL(domain, alias string) error
+	IsWorkspaceMember(workspaceID, uid int64) (bool, error)
 }
 
 //go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=URLDeleter
-func New(log *slog.Logger, urldeleter URLDeleter) http.HandlerFunc {
+func New(log *slog.Logger, urldeleter URLDeleter, domains []string) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.url.delete.new"
 
-		log = log.With(
+		log := log.With(
 			slog.String("op", op),
 			slog.String("request_id", middleware.GetReqID(r.Context())),
 		)
 
-		err := auth.CheckPermission(r.Context())
+		user, err := auth.CurrentUser(r.Context())
 		if err != nil {
-			if errors.Is(err, auth.ErrConvert) {
-				log.Error("failed to convert", sl.Err(err))
-				render.JSON(w, r, response.Error("failed to convert token"))
-			} else if errors.Is(err, auth.ErrFailedAdminCheck) {
-				log.Error("failed to check if user is admin", sl.Err(err))
-				render.JSON(w, r, response.Error("failed to check if user is admin"))
-			} else if errors.Is(err, auth.ErrInvalidToken) {
-				log.Error("invalid token", sl.Err(err))
-				render.JSON(w, r, response.Error("invalid token"))
-			} else if errors.Is(err, auth.ErrPermissionDenied) {
-				log.Error("don't have permission to action", sl.Err(err))
-				render.JSON(w, r, response.Error("don't have permission to action"))
-			} else {
-				log.Error("internal error", sl.Err(err))
-				render.JSON(w, r, response.Error("internal error"))
-			}
+			log.Error("failed to get user", sl.Err(err))
+			response.Fail(w, r, response.ErrInternal)
 			return
 		}
 
 		alias := chi.URLParam(r, "alias")
 		if alias == "" {
 			log.Error("alias is empty")
-			render.JSON(w, r, response.Error("invalid request"))
+			response.Fail(w, r, response.ErrInvalidRequest)
+			return
+		}
+
+		linkDomain, ok := domain.FromRequest(r, domains)
+		if !ok {
+			log.Info("domain is not allowed", slog.String("domain", r.URL.Query().Get(domain.QueryParam)))
+			response.Fail(w, r, response.Unprocessable("domain_not_allowed", "domain is not allowed"))
 			return
 		}
 
-		err = urldeleter.DeleteURL(alias)
+		// admins delete any link, users only own links and links of their workspaces
+		if !user.IsAdmin {
+			allowed, err := canDelete(urldeleter, linkDomain, alias, user)
+			if err != nil {
+				if errors.Is(err, storage.ErrURLNotFound) {
+					log.Info("url not found", slog.String("alias", alias))
+					response.Fail(w, r, response.NotFound("url_not_found", "url not found"))
+				} else {
+					log.Error("failed to check workspace member", sl.Err(err))
+					response.Fail(w, r, response.ErrInternal)
+				}
+				return
+			}
+			if !allowed {
+				log.Info("user is neither the owner nor a workspace member", slog.Int64("uid", user.UID))
+				response.Fail(w, r, response.ErrPermissionDenied)
+				return
+			}
+		}
+
+		err = urldeleter.DeleteURL(linkDomain, alias)
 		if err != nil {
 			if errors.Is(err, storage.ErrURLNotFound) {
-				log.Info("url not found")
+				log.Info("url not found", slog.String("alias", alias))
+				response.Fail(w, r, response.NotFound("url_not_found", "url not found"))
 			} else {
 				log.Error("failed to delete url", sl.Err(err))
-				render.JSON(w, r, response.Error("internal error"))
-				return
+				response.Fail(w, r, response.ErrInternal)
 			}
+			return
 		}
 
 		log.Info("url deleted")
 		render.JSON(w, r, response.OK())
 	}
 }
+
+// canDelete checks if the user owns the link or is a member of the link workspace
+func canDelete(urldeleter URLDeleter, linkDomain, alias string, user auth.User) (bool, error) {
+	url, err := urldeleter.URL(linkDomain, alias)
+	if err != nil {
+		return false, err
+	}
+	if url.OwnerUID == user.UID {
+		return true, nil
+	}
+	if url.Workspace == nil {
+		return false, nil
+	}
+
+	return urldeleter.IsWorkspaceMember(url.Workspace.ID, user.UID)
+}
@@ -3,15 +3,19 @@ package delete_test
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
+	"url-shortener/domain/models"
 	"url-shortener/internal/http-server/handlers/url/delete"
 	"url-shortener/internal/http-server/handlers/url/delete/mocks"
+	"url-shortener/internal/http-server/middleware/auth"
 	"url-shortener/internal/lib/api/response"
 	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/storage"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/stretchr/testify/require"
@@ -21,8 +25,16 @@ func TestNew(t *testing.T) {
 	cases := []struct {
 		name      string
 		alias     string
+		query     string
+		domain    string
 		url       string
+		nonAdmin  bool
+		ownerUID  int64
+		workspace *models.Workspace
+		member    bool
+		urlError  error
 		respError string
+		status    int
 		mockError error
 	}{
 		{
@@ -30,36 +42,123 @@ func TestNew(t *testing.T) {
 			alias: "test_alias",
 			url:   "https://google.com",
 		},
+		{
+			name:     "Owner",
+			alias:    "test_alias",
+			url:      "https://google.com",
+			nonAdmin: true,
+			ownerUID: 1,
+		},
+		{
+			name:      "Workspace member",
+			alias:     "test_alias",
+			url:       "https://google.com",
+			nonAdmin:  true,
+			workspace: &models.Workspace{ID: 3, Name: "marketing"},
+			member:    true,
+		},
+		{
+			name:      "Not a workspace member",
+			alias:     "test_alias",
+			url:       "https://google.com",
+			nonAdmin:  true,
+			workspace: &models.Workspace{ID: 3, Name: "marketing"},
+			respError: "don't have permission to action",
+			status:    http.StatusForbidden,
+		},
+		{
+			name:      "Foreign link without workspace",
+			alias:     "test_alias",
+			url:       "https://google.com",
+			nonAdmin:  true,
+			ownerUID:  2,
+			respError: "don't have permission to action",
+			status:    http.StatusForbidden,
+		},
+		{
+			name:      "Non-admin and not found",
+			alias:     "test_alias",
+			nonAdmin:  true,
+			urlError:  storage.ErrURLNotFound,
+			respError: "url not found",
+			status:    http.StatusNotFound,
+		},
+		{
+			name:      "Admin and not found",
+			alias:     "test_alias",
+			respError: "url not found",
+			status:    http.StatusNotFound,
+			mockError: storage.ErrURLNotFound,
+		},
+		{
+			name:   "Allowed domain",
+			alias:  "test_alias",
+			query:  "?domain=go.brand-a.com",
+			domain: "go.brand-a.com",
+			url:    "https://google.com",
+		},
+		{
+			name:      "Domain not allowed",
+			alias:     "test_alias",
+			query:     "?domain=evil.com",
+			respError: "domain is not allowed",
+			status:    http.StatusUnprocessableEntity,
+		},
+		{
+			name:      "DeleteURL Error",
+			alias:     "test_alias",
+			url:       "https://google.com",
+			respError: "internal error",
+			status:    http.StatusInternalServerError,
+			mockError: errors.New("unexpected error"),
+		},
 	}
 
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
 			urlDeleterMock := mocks.NewURLDeleter(t)
 
-			if tc.respError == "" || tc.mockError != nil {
-				if tc.alias != "" {
-					urlDeleterMock.On("DeleteURL", tc.alias).
-						Return(tc.mockError).
+			if tc.nonAdmin {
+				urlDeleterMock.On("URL", tc.domain, tc.alias).
+					Return(models.URL{Alias: tc.alias, URL: tc.url, OwnerUID: tc.ownerUID, Workspace: tc.workspace}, tc.urlError).
+					Once()
+				if tc.workspace != nil {
+					urlDeleterMock.On("IsWorkspaceMember", tc.workspace.ID, int64(1)).
+						Return(tc.member, nil).
 						Once()
 				}
 			}
+			if (tc.respError == "" || tc.mockError != nil) && tc.urlError == nil {
+				urlDeleterMock.On("DeleteURL", tc.domain, tc.alias).
+					Return(tc.mockError).
+					Once()
+			}
 
 			r := chi.NewRouter()
-			r.Delete("/url/{alias}", delete.New(slogdiscard.NewDiscardLogger(), urlDeleterMock))
+			r.Delete("/url/{alias}", delete.New(slogdiscard.NewDiscardLogger(), urlDeleterMock, []string{"go.brand-a.com"}))
 
-			input := fmt.Sprintf("/url/%s", tc.alias)
+			input := fmt.Sprintf("/url/%s%s", tc.alias, tc.query)
 
 			req, err := http.NewRequest(http.MethodDelete, input, bytes.NewReader([]byte{}))
 			require.NoError(t, err)
+			req = req.WithContext(auth.WithUser(req.Context(), auth.User{UID: 1, IsAdmin: !tc.nonAdmin}))
 
 			rr := httptest.NewRecorder()
 			r.ServeHTTP(rr, req)
-			require.Equal(t, rr.Code, http.StatusOK)
+			if tc.respError != "" {
+				require.Equal(t, tc.status, rr.Code)
+				require.Equal(t, response.ContentTypeProblem, rr.Header().Get("Content-Type"))
+
+				var problem response.Problem
+				require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &problem))
+				require.Equal(t, tc.respError, problem.Detail)
+				return
+			}
+			require.Equal(t, http.StatusOK, rr.Code)
 
 			body := rr.Body.String()
 			var resp response.Response
 			require.NoError(t, json.Unmarshal([]byte(body), &resp))
-			require.Equal(t, tc.respError, resp.Error)
 		})
 	}
 }
@@ -2,20 +2,24 @@
 
 package mocks
 
-import mock "github.com/stretchr/testify/mock"
+import (
+	mock "github.com/stretchr/testify/mock"
+
+	models "url-shortener/domain/models"
+)
 
 // URLDeleter is an autogenerated mock type for the URLDeleter type
 type URLDeleter struct {
 	mock.Mock
 }
 
-// DeleteURL provides a mock function with given fields: alias
-func (_m *URLDeleter) DeleteURL(alias string) error {
-	ret := _m.Called(alias)
+// DeleteURL provides a mock function with given fields: domain, alias
+func (_m *URLDeleter) DeleteURL(domain string, alias string) error {
+	ret := _m.Called(domain, alias)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string) error); ok {
-		r0 = rf(alias)
+	if rf, ok := ret.Get(0).(func(string, string) error); ok {
+		r0 = rf(domain, alias)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -23,6 +27,54 @@ func (_m *URLDeleter) DeleteURL(alias string) error {
 	return r0
 }
 
+// IsWorkspaceMember provides a mock function with given fields: workspaceID, uid
+func (_m *URLDeleter) IsWorkspaceMember(workspaceID int64, uid int64) (bool, error) {
+	ret := _m.Called(workspaceID, uid)
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(int64, int64) (bool, error)); ok {
+		return rf(workspaceID, uid)
+	}
+	if rf, ok := ret.Get(0).(func(int64, int64) bool); ok {
+		r0 = rf(workspaceID, uid)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(int64, int64) error); ok {
+		r1 = rf(workspaceID, uid)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// URL provides a mock function with given fields: domain, alias
+func (_m *URLDeleter) URL(domain string, alias string) (models.URL, error) {
+	ret := _m.Called(domain, alias)
+
+	var r0 models.URL
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, string) (models.URL, error)); ok {
+		return rf(domain, alias)
+	}
+	if rf, ok := ret.Get(0).(func(string, string) models.URL); ok {
+		r0 = rf(domain, alias)
+	} else {
+		r0 = ret.Get(0).(models.URL)
+	}
+
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(domain, alias)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 type mockConstructorTestingTNewURLDeleter interface {
 	mock.TestingT
 	Cleanup(func())
@@ -0,0 +1,154 @@
+package list
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+
+	"url-shortener/domain/models"
+	"url-shortener/internal/http-server/middleware/auth"
+	"url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/storage"
+)
+
+const (
+	defaultLimit = 100
+	maxLimit     = 1000
+)
+
+type Link struct {
+	Alias     string    `json:"alias"`
+	Domain    string    `json:"domain,omitempty"`
+	URL       string    `json:"url"`
+	Workspace string    `json:"workspace,omitempty"`
+	OwnerUID  int64     `json:"owner_uid,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Response struct {
+	response.Response
+	Links []Link `json:"links"`
+}
+
+type URLsProvider interface {
+	URLs(filter storage.URLFilter) ([]models.URL, error)
+	Workspace(name string) (models.Workspace, error)
+	IsWorkspaceMember(workspaceID, uid int64) (bool, error)
+}
+
+// New lists links visible to the user: admins see all links, users see own links
+// and links of their workspaces. The list may be narrowed by ?workspace=name
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=URLsProvider
+func New(log *slog.Logger, urlsProvider URLsProvider) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.list.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		user, err := auth.CurrentUser(r.Context())
+		if err != nil {
+			log.Error("failed to get user", sl.Err(err))
+			response.Fail(w, r, response.ErrInternal)
+			return
+		}
+
+		filter, ok := page(r)
+		if !ok {
+			log.Info("invalid pagination", slog.String("query", r.URL.RawQuery))
+			response.Fail(w, r, response.ErrInvalidRequest)
+			return
+		}
+
+		if name := r.URL.Query().Get("workspace"); name != "" {
+			ws, err := urlsProvider.Workspace(name)
+			if err != nil {
+				if errors.Is(err, storage.ErrWorkspaceNotFound) {
+					log.Info("workspace not found", slog.String("workspace", name))
+					response.Fail(w, r, response.NotFound("workspace_not_found", "workspace not found"))
+				} else {
+					log.Error("failed to get workspace", sl.Err(err))
+					response.Fail(w, r, response.ErrInternal)
+				}
+				return
+			}
+
+			if !user.IsAdmin {
+				member, err := urlsProvider.IsWorkspaceMember(ws.ID, user.UID)
+				if err != nil {
+					log.Error("failed to check workspace member", sl.Err(err))
+					response.Fail(w, r, response.ErrInternal)
+					return
+				}
+				if !member {
+					log.Info("user is not a workspace member", slog.Int64("uid", user.UID))
+					response.Fail(w, r, response.ErrPermissionDenied)
+					return
+				}
+			}
+			filter.WorkspaceID = ws.ID
+		} else if !user.IsAdmin {
+			filter.UID = user.UID
+		}
+
+		urls, err := urlsProvider.URLs(filter)
+		if err != nil {
+			log.Error("failed to get urls", sl.Err(err))
+			response.Fail(w, r, response.ErrInternal)
+			return
+		}
+
+		resp := Response{
+			Response: response.OK(),
+			Links:    make([]Link, 0, len(urls)),
+		}
+		for _, url := range urls {
+			link := Link{
+				Alias:     url.Alias,
+				Domain:    url.Domain,
+				URL:       url.URL,
+				OwnerUID:  url.OwnerUID,
+				CreatedAt: url.CreatedAt,
+			}
+			if url.Workspace != nil {
+				link.Workspace = url.Workspace.Name
+			}
+			resp.Links = append(resp.Links, link)
+		}
+
+		log.Info("urls listed", slog.Int("count", len(urls)))
+		render.JSON(w, r, resp)
+	}
+}
+
+// page reads limit and offset from the query
+func page(r *http.Request) (storage.URLFilter, bool) {
+	filter := storage.URLFilter{Limit: defaultLimit}
+
+	query := r.URL.Query()
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxLimit {
+			return storage.URLFilter{}, false
+		}
+		filter.Limit = limit
+	}
+	if value := query.Get("offset"); value != "" {
+		offset, err := strconv.Atoi(value)
+		if err != nil || offset < 0 {
+			return storage.URLFilter{}, false
+		}
+		filter.Offset = offset
+	}
+
+	return filter, true
+}
@@ -0,0 +1,151 @@
+package list_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"url-shortener/domain/models"
+	"url-shortener/internal/http-server/handlers/url/list"
+	"url-shortener/internal/http-server/handlers/url/list/mocks"
+	"url-shortener/internal/http-server/middleware/auth"
+	"url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/storage"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	marketing := &models.Workspace{ID: 3, Name: "marketing"}
+
+	cases := []struct {
+		name      string
+		query     string
+		nonAdmin  bool
+		workspace string
+		wsError   error
+		member    bool
+		filter    *storage.URLFilter
+		urls      []models.URL
+		respError string
+		status    int
+		mockError error
+	}{
+		{
+			name:   "Admin sees all",
+			filter: &storage.URLFilter{Limit: 100},
+			urls: []models.URL{
+				{Alias: "a", URL: "https://a.example.com"},
+				{Alias: "b", URL: "https://b.example.com", Domain: "go.brand-a.com", Workspace: marketing},
+			},
+		},
+		{
+			name:     "User sees own links and workspaces",
+			query:    "?limit=10&offset=20",
+			nonAdmin: true,
+			filter:   &storage.URLFilter{UID: 1, Limit: 10, Offset: 20},
+			urls: []models.URL{
+				{Alias: "a", URL: "https://a.example.com", OwnerUID: 1},
+				{Alias: "b", URL: "https://b.example.com", OwnerUID: 2, Workspace: marketing},
+			},
+		},
+		{
+			name:      "Member filters by workspace",
+			query:     "?workspace=marketing",
+			nonAdmin:  true,
+			workspace: "marketing",
+			member:    true,
+			filter:    &storage.URLFilter{WorkspaceID: 3, Limit: 100},
+		},
+		{
+			name:      "Foreign workspace",
+			query:     "?workspace=marketing",
+			nonAdmin:  true,
+			workspace: "marketing",
+			respError: "don't have permission to action",
+			status:    http.StatusForbidden,
+		},
+		{
+			name:      "Admin filters by workspace",
+			query:     "?workspace=marketing",
+			workspace: "marketing",
+			filter:    &storage.URLFilter{WorkspaceID: 3, Limit: 100},
+		},
+		{
+			name:      "Unknown workspace",
+			query:     "?workspace=sales",
+			workspace: "sales",
+			wsError:   storage.ErrWorkspaceNotFound,
+			respError: "workspace not found",
+			status:    http.StatusNotFound,
+		},
+		{
+			name:      "Invalid limit",
+			query:     "?limit=5000",
+			respError: "invalid request",
+			status:    http.StatusBadRequest,
+		},
+		{
+			name:      "URLs Error",
+			filter:    &storage.URLFilter{Limit: 100},
+			respError: "internal error",
+			status:    http.StatusInternalServerError,
+			mockError: errors.New("unexpected error"),
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			urlsProviderMock := mocks.NewURLsProvider(t)
+			if tc.workspace != "" {
+				urlsProviderMock.On("Workspace", tc.workspace).
+					Return(models.Workspace{ID: 3, Name: tc.workspace}, tc.wsError).
+					Once()
+				if tc.nonAdmin {
+					urlsProviderMock.On("IsWorkspaceMember", int64(3), int64(1)).
+						Return(tc.member, nil).
+						Once()
+				}
+			}
+			if tc.filter != nil {
+				urlsProviderMock.On("URLs", *tc.filter).
+					Return(tc.urls, tc.mockError).
+					Once()
+			}
+
+			handler := list.New(slogdiscard.NewDiscardLogger(), urlsProviderMock)
+
+			req, err := http.NewRequest(http.MethodGet, "/"+tc.query, nil)
+			require.NoError(t, err)
+			req = req.WithContext(auth.WithUser(req.Context(), auth.User{UID: 1, IsAdmin: !tc.nonAdmin}))
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+			if tc.respError != "" {
+				require.Equal(t, tc.status, rr.Code)
+				require.Equal(t, response.ContentTypeProblem, rr.Header().Get("Content-Type"))
+
+				var problem response.Problem
+				require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &problem))
+				require.Equal(t, tc.respError, problem.Detail)
+				return
+			}
+			require.Equal(t, http.StatusOK, rr.Code)
+
+			var resp list.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			require.Len(t, resp.Links, len(tc.urls))
+			for i, url := range tc.urls {
+				require.Equal(t, url.Alias, resp.Links[i].Alias)
+				require.Equal(t, url.Domain, resp.Links[i].Domain)
+				require.Equal(t, url.OwnerUID, resp.Links[i].OwnerUID)
+				if url.Workspace != nil {
+					require.Equal(t, url.Workspace.Name, resp.Links[i].Workspace)
+				}
+			}
+		})
+	}
+}
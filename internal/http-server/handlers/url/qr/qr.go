@@ -0,0 +1,254 @@
+package qr
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image/color"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"url-shortener/domain/models"
+	"url-shortener/internal/http-server/middleware/domain"
+	"url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/lib/qrcode"
+	"url-shortener/internal/storage"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+const (
+	FormatPNG = "png"
+	FormatSVG = "svg"
+)
+
+const (
+	defaultSize   = 256
+	minSize       = 64
+	maxSize       = 2048
+	defaultMargin = 4
+	maxMargin     = 16
+	// maxAge of the generated image, it depends only on the short link and the query
+	maxAge = 24 * 60 * 60
+)
+
+// Suffix follows the alias in the path of QR code, passthrough links don't pass it and its
+// extensions like qr.svg to the destination since the QR code routes match them first
+const Suffix = "qr"
+
+type URLGetter interface {
+	URL(domain, alias string) (models.URL, error)
+}
+
+// Routes registers the QR code endpoint next to the short links, the format may be given by the path extension
+func Routes(log *slog.Logger, urlGetter URLGetter, baseURL string) func(r chi.Router) {
+	return func(r chi.Router) {
+		handler := New(log, urlGetter, baseURL)
+		r.Get("/{alias}/"+Suffix, handler)
+		r.Get("/{alias}/"+Suffix+".{format}", handler)
+	}
+}
+
+// New returns QR code of the short link. baseURL is the scheme and host the short link
+// is built with, the request scheme and host are used if it is empty.
+// Such images depend on the Host header, so they are not stored by shared caches
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=URLGetter
+func New(log *slog.Logger, urlGetter URLGetter, baseURL string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.qr.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		alias := chi.URLParam(r, "alias")
+		if alias == "" {
+			log.Error("alias is empty")
+			response.Fail(w, r, response.ErrInvalidRequest)
+			return
+		}
+
+		format, opts, err := parseOptions(r)
+		if err != nil {
+			log.Info("invalid qr options", sl.Err(err))
+			response.Fail(w, r, response.BadRequest("invalid_qr_options", err.Error()))
+			return
+		}
+
+		url, err := urlGetter.URL(domain.FromContext(r.Context()), alias)
+		if err != nil {
+			if errors.Is(err, storage.ErrURLNotFound) {
+				log.Info("url not found", slog.String("alias", alias))
+				response.Fail(w, r, response.NotFound("url_not_found", "url not found"))
+			} else {
+				log.Error("failed to get url", sl.Err(err))
+				response.Fail(w, r, response.ErrInternal)
+			}
+			return
+		}
+
+		content := shortURL(r, baseURL, url)
+		etag := entityTag(content, format, opts)
+
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Cache-Control", cacheControl(baseURL))
+		if match(r.Header.Get("If-None-Match"), etag) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		code, err := qrcode.New(content, opts)
+		if err != nil {
+			log.Error("failed to encode qr code", sl.Err(err))
+			response.Fail(w, r, response.ErrInternal)
+			return
+		}
+
+		var body []byte
+		switch format {
+		case FormatSVG:
+			w.Header().Set("Content-Type", "image/svg+xml")
+			body = code.SVG()
+		default:
+			w.Header().Set("Content-Type", "image/png")
+			body, err = code.PNG()
+			if err != nil {
+				log.Error("failed to render qr code", sl.Err(err))
+				response.Fail(w, r, response.ErrInternal)
+				return
+			}
+		}
+
+		log.Info("qr code generated", slog.String("alias", alias), slog.String("format", format))
+
+		if _, err := w.Write(body); err != nil {
+			log.Error("failed to write qr code", sl.Err(err))
+		}
+	}
+}
+
+// cacheControl allows shared caches to store the image only if the short link doesn't depend on the request host
+func cacheControl(baseURL string) string {
+	if baseURL == "" {
+		return fmt.Sprintf("private, max-age=%d", maxAge)
+	}
+	return fmt.Sprintf("public, max-age=%d", maxAge)
+}
+
+// parseOptions reads image format and rendering options from the query.
+// The format may also be given by the path extension, e.g. /abc123/qr.svg
+func parseOptions(r *http.Request) (string, qrcode.Options, error) {
+	query := r.URL.Query()
+
+	format := query.Get("format")
+	if format == "" {
+		format = chi.URLParam(r, "format")
+	}
+	format = strings.ToLower(format)
+	switch format {
+	case "":
+		format = FormatPNG
+	case FormatPNG, FormatSVG:
+	default:
+		return "", qrcode.Options{}, fmt.Errorf("format must be one of %s %s", FormatPNG, FormatSVG)
+	}
+
+	opts := qrcode.Options{
+		Size:   defaultSize,
+		Level:  strings.ToUpper(query.Get("level")),
+		Margin: defaultMargin,
+	}
+	switch opts.Level {
+	case "":
+		opts.Level = qrcode.LevelMedium
+	case qrcode.LevelLow, qrcode.LevelMedium, qrcode.LevelQuartile, qrcode.LevelHigh:
+	default:
+		return "", qrcode.Options{}, fmt.Errorf("level must be one of %s %s %s %s",
+			qrcode.LevelLow, qrcode.LevelMedium, qrcode.LevelQuartile, qrcode.LevelHigh)
+	}
+
+	var err error
+	if opts.Size, err = intParam(query.Get("size"), defaultSize, minSize, maxSize); err != nil {
+		return "", qrcode.Options{}, fmt.Errorf("size %w", err)
+	}
+	if opts.Margin, err = intParam(query.Get("margin"), defaultMargin, 0, maxMargin); err != nil {
+		return "", qrcode.Options{}, fmt.Errorf("margin %w", err)
+	}
+
+	if opts.Foreground, err = colorParam(query.Get("fg"), "000000"); err != nil {
+		return "", qrcode.Options{}, errors.New("fg is not a valid color")
+	}
+	if opts.Background, err = colorParam(query.Get("bg"), "ffffff"); err != nil {
+		return "", qrcode.Options{}, errors.New("bg is not a valid color")
+	}
+
+	return format, opts, nil
+}
+
+func intParam(value string, def, lo, hi int) (int, error) {
+	if value == "" {
+		return def, nil
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil || n < lo || n > hi {
+		return 0, fmt.Errorf("must be a number from %d to %d", lo, hi)
+	}
+
+	return n, nil
+}
+
+func colorParam(value string, def string) (color.RGBA, error) {
+	if value == "" {
+		value = def
+	}
+	return qrcode.ParseColor(value)
+}
+
+// shortURL builds the short link encoded into QR code.
+// Links of custom domains keep the scheme of the base URL
+func shortURL(r *http.Request, baseURL string, url models.URL) string {
+	if baseURL == "" {
+		scheme := "http"
+		if r.TLS != nil {
+			scheme = "https"
+		}
+		baseURL = scheme + "://" + r.Host
+	}
+
+	if url.Domain != "" {
+		scheme, _, _ := strings.Cut(baseURL, "://")
+		baseURL = scheme + "://" + url.Domain
+	}
+
+	return strings.TrimSuffix(baseURL, "/") + "/" + url.Alias
+}
+
+// entityTag identifies the image, it is the same for the same content and options
+func entityTag(content string, format string, opts qrcode.Options) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s|%s|%d|%s|%d|%s|%s",
+		content, format, opts.Size, opts.Level, opts.Margin,
+		qrcode.Hex(opts.Foreground), qrcode.Hex(opts.Background),
+	)))
+
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// match reports whether If-None-Match header lists the entity tag
+func match(header string, etag string) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == etag || tag == "*" {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,242 @@
+package qr_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"url-shortener/domain/models"
+	"url-shortener/internal/http-server/handlers/url/qr"
+	"url-shortener/internal/http-server/handlers/url/qr/mocks"
+	"url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/storage"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newRouter(urlGetter qr.URLGetter) http.Handler {
+	r := chi.NewRouter()
+	r.Group(qr.Routes(slogdiscard.NewDiscardLogger(), urlGetter, "https://sho.rt"))
+	return r
+}
+
+func TestNew(t *testing.T) {
+	cases := []struct {
+		name        string
+		path        string
+		contentType string
+		respError   string
+		status      int
+		mockError   error
+		// noLookup is set when the request is rejected before the url lookup
+		noLookup bool
+	}{
+		{
+			name:        "PNG by default",
+			path:        "/test_alias/qr",
+			contentType: "image/png",
+		},
+		{
+			name:        "SVG by query",
+			path:        "/test_alias/qr?format=svg&size=512&level=h&margin=0&fg=%23336699&bg=fff",
+			contentType: "image/svg+xml",
+		},
+		{
+			name:        "SVG by extension",
+			path:        "/test_alias/qr.svg",
+			contentType: "image/svg+xml",
+		},
+		{
+			name:      "Invalid format",
+			path:      "/test_alias/qr?format=gif",
+			respError: "format must be one of png svg",
+			status:    http.StatusBadRequest,
+			noLookup:  true,
+		},
+		{
+			name:      "Invalid size",
+			path:      "/test_alias/qr?size=10000",
+			respError: "size must be a number from 64 to 2048",
+			status:    http.StatusBadRequest,
+			noLookup:  true,
+		},
+		{
+			name:      "Invalid level",
+			path:      "/test_alias/qr?level=X",
+			respError: "level must be one of L M Q H",
+			status:    http.StatusBadRequest,
+			noLookup:  true,
+		},
+		{
+			name:      "Invalid margin",
+			path:      "/test_alias/qr?margin=-1",
+			respError: "margin must be a number from 0 to 16",
+			status:    http.StatusBadRequest,
+			noLookup:  true,
+		},
+		{
+			name:      "Invalid color",
+			path:      "/test_alias/qr?fg=blue",
+			respError: "fg is not a valid color",
+			status:    http.StatusBadRequest,
+			noLookup:  true,
+		},
+		{
+			name:      "Not found",
+			path:      "/test_alias/qr",
+			respError: "url not found",
+			status:    http.StatusNotFound,
+			mockError: storage.ErrURLNotFound,
+		},
+		{
+			name:      "URL Error",
+			path:      "/test_alias/qr",
+			respError: "internal error",
+			status:    http.StatusInternalServerError,
+			mockError: errors.New("unexpected error"),
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			urlGetterMock := mocks.NewURLGetter(t)
+			if !tc.noLookup {
+				urlGetterMock.On("URL", "", "test_alias").
+					Return(models.URL{Alias: "test_alias", URL: "https://google.com"}, tc.mockError).
+					Once()
+			}
+
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			rr := httptest.NewRecorder()
+			newRouter(urlGetterMock).ServeHTTP(rr, req)
+
+			if tc.respError != "" {
+				require.Equal(t, tc.status, rr.Code)
+
+				var problem response.Problem
+				require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &problem))
+				assert.Equal(t, tc.respError, problem.Detail)
+				return
+			}
+			require.Equal(t, http.StatusOK, rr.Code)
+
+			assert.Equal(t, tc.contentType, rr.Header().Get("Content-Type"))
+			assert.NotEmpty(t, rr.Header().Get("ETag"))
+			assert.Equal(t, "public, max-age=86400", rr.Header().Get("Cache-Control"))
+
+			if tc.contentType == "image/png" {
+				img, err := png.Decode(bytes.NewReader(rr.Body.Bytes()))
+				require.NoError(t, err)
+				assert.Equal(t, 256, img.Bounds().Dx())
+			} else {
+				assert.True(t, strings.HasPrefix(rr.Body.String(), "<svg "))
+			}
+		})
+	}
+}
+
+func TestNew_ETag(t *testing.T) {
+	urlGetterMock := mocks.NewURLGetter(t)
+	urlGetterMock.On("URL", "", "test_alias").
+		Return(models.URL{Alias: "test_alias", URL: "https://google.com"}, nil)
+
+	router := newRouter(urlGetterMock)
+
+	get := func(path string, etag string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	first := get("/test_alias/qr", "")
+	require.Equal(t, http.StatusOK, first.Code)
+	etag := first.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+
+	assert.Equal(t, etag, get("/test_alias/qr", "").Header().Get("ETag"), "etag must be stable")
+	assert.NotEqual(t, etag, get("/test_alias/qr?size=512", "").Header().Get("ETag"))
+	assert.NotEqual(t, etag, get("/test_alias/qr.svg", "").Header().Get("ETag"))
+
+	notModified := get("/test_alias/qr", `"other", `+etag)
+	assert.Equal(t, http.StatusNotModified, notModified.Code)
+	assert.Empty(t, notModified.Body.Bytes())
+
+	assert.Equal(t, http.StatusOK, get("/test_alias/qr", `"other"`).Code)
+}
+
+func TestRoutes_ReservedSuffix(t *testing.T) {
+	cases := []struct {
+		name string
+		path string
+		qr   bool
+	}{
+		{name: "QR code", path: "/test_alias/qr", qr: true},
+		{name: "QR code with extension", path: "/test_alias/qr.svg", qr: true},
+		{name: "Path under suffix", path: "/test_alias/qr/extra"},
+		{name: "Suffix prefix", path: "/test_alias/qrcode"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			urlGetterMock := mocks.NewURLGetter(t)
+			if tc.qr {
+				urlGetterMock.On("URL", "", "test_alias").
+					Return(models.URL{Alias: "test_alias", URL: "https://google.com", Passthrough: true}, nil).
+					Once()
+			}
+
+			// the passthrough redirect is registered like in the service, QR code routes take precedence
+			r := chi.NewRouter()
+			r.Group(qr.Routes(slogdiscard.NewDiscardLogger(), urlGetterMock, "https://sho.rt"))
+			r.Get("/{alias}/*", func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusFound)
+			})
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, tc.path, nil))
+
+			if tc.qr {
+				assert.Equal(t, http.StatusOK, rr.Code)
+				return
+			}
+			assert.Equal(t, http.StatusFound, rr.Code)
+		})
+	}
+}
+
+func TestNew_RequestHost(t *testing.T) {
+	urlGetterMock := mocks.NewURLGetter(t)
+	urlGetterMock.On("URL", "", "test_alias").
+		Return(models.URL{Alias: "test_alias", URL: "https://google.com"}, nil)
+
+	r := chi.NewRouter()
+	r.Group(qr.Routes(slogdiscard.NewDiscardLogger(), urlGetterMock, ""))
+
+	get := func(host string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/test_alias/qr", nil)
+		req.Host = host
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		return rr
+	}
+
+	first, second := get("sho.rt"), get("evil.example")
+	require.Equal(t, http.StatusOK, first.Code)
+	require.Equal(t, http.StatusOK, second.Code)
+
+	// the short link is built from the request host, shared caches must not serve it for other hosts
+	assert.Equal(t, "private, max-age=86400", first.Header().Get("Cache-Control"))
+	assert.NotEqual(t, first.Header().Get("ETag"), second.Header().Get("ETag"))
+}
@@ -2,30 +2,60 @@
 
 package mocks
 
-import mock "github.com/stretchr/testify/mock"
+import (
+	mock "github.com/stretchr/testify/mock"
+
+	models "url-shortener/domain/models"
+
+	storage "url-shortener/internal/storage"
+)
 
 // URLSaver is an autogenerated mock type for the URLSaver type
 type URLSaver struct {
 	mock.Mock
 }
 
-// GetURL provides a mock function with given fields: alias
-func (_m *URLSaver) GetURL(alias string) (string, error) {
-	ret := _m.Called(alias)
+// AliasExists provides a mock function with given fields: domain, alias, fold
+func (_m *URLSaver) AliasExists(domain string, alias string, fold storage.AliasFold) (bool, error) {
+	ret := _m.Called(domain, alias, fold)
 
-	var r0 string
+	var r0 bool
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string) (string, error)); ok {
-		return rf(alias)
+	if rf, ok := ret.Get(0).(func(string, string, storage.AliasFold) (bool, error)); ok {
+		return rf(domain, alias, fold)
 	}
-	if rf, ok := ret.Get(0).(func(string) string); ok {
-		r0 = rf(alias)
+	if rf, ok := ret.Get(0).(func(string, string, storage.AliasFold) bool); ok {
+		r0 = rf(domain, alias, fold)
 	} else {
-		r0 = ret.Get(0).(string)
+		r0 = ret.Get(0).(bool)
 	}
 
-	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(alias)
+	if rf, ok := ret.Get(1).(func(string, string, storage.AliasFold) error); ok {
+		r1 = rf(domain, alias, fold)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// IsWorkspaceMember provides a mock function with given fields: workspaceID, uid
+func (_m *URLSaver) IsWorkspaceMember(workspaceID int64, uid int64) (bool, error) {
+	ret := _m.Called(workspaceID, uid)
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(int64, int64) (bool, error)); ok {
+		return rf(workspaceID, uid)
+	}
+	if rf, ok := ret.Get(0).(func(int64, int64) bool); ok {
+		r0 = rf(workspaceID, uid)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(int64, int64) error); ok {
+		r1 = rf(workspaceID, uid)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -33,13 +63,13 @@ func (_m *URLSaver) GetURL(alias string) (string, error) {
 	return r0, r1
 }
 
-// SaveURL provides a mock function with given fields: urlToSave, alias
-func (_m *URLSaver) SaveURL(urlToSave string, alias string) error {
-	ret := _m.Called(urlToSave, alias)
+// SaveURL provides a mock function with given fields: url
+func (_m *URLSaver) SaveURL(url models.URL) error {
+	ret := _m.Called(url)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string, string) error); ok {
-		r0 = rf(urlToSave, alias)
+	if rf, ok := ret.Get(0).(func(models.URL) error); ok {
+		r0 = rf(url)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -47,6 +77,54 @@ func (_m *URLSaver) SaveURL(urlToSave string, alias string) error {
 	return r0
 }
 
+// UTMTemplate provides a mock function with given fields: name
+func (_m *URLSaver) UTMTemplate(name string) (models.UTMTemplate, error) {
+	ret := _m.Called(name)
+
+	var r0 models.UTMTemplate
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (models.UTMTemplate, error)); ok {
+		return rf(name)
+	}
+	if rf, ok := ret.Get(0).(func(string) models.UTMTemplate); ok {
+		r0 = rf(name)
+	} else {
+		r0 = ret.Get(0).(models.UTMTemplate)
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Workspace provides a mock function with given fields: name
+func (_m *URLSaver) Workspace(name string) (models.Workspace, error) {
+	ret := _m.Called(name)
+
+	var r0 models.Workspace
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (models.Workspace, error)); ok {
+		return rf(name)
+	}
+	if rf, ok := ret.Get(0).(func(string) models.Workspace); ok {
+		r0 = rf(name)
+	} else {
+		r0 = ret.Get(0).(models.Workspace)
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 type mockConstructorTestingTNewURLSaver interface {
 	mock.TestingT
 	Cleanup(func())
@@ -4,62 +4,84 @@ import (
 	"errors"
 	"log/slog"
 	"net/http"
+	"time"
 
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/render"
 	"github.com/go-playground/validator/v10"
 
+	"url-shortener/domain/models"
 	"url-shortener/internal/config"
 	"url-shortener/internal/http-server/middleware/auth"
+	"url-shortener/internal/http-server/middleware/domain"
+	"url-shortener/internal/lib/aliaspolicy"
 	"url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/destination"
 	"url-shortener/internal/lib/logger/sl"
 	"url-shortener/internal/lib/random"
 	"url-shortener/internal/storage"
 )
 
 type Request struct {
-	URL   string `json:"url" validate:"required,url"`
-	Alias string `json:"alias,omitempty"`
+	URL           string    `json:"url" validate:"required,url"`
+	Alias         string    `json:"alias,omitempty"`
+	Domain        string    `json:"domain,omitempty"`
+	Workspace     string    `json:"workspace,omitempty"`
+	RedirectCode  int       `json:"redirect_code,omitempty" validate:"omitempty,oneof=301 302 303 307 308"`
+	CacheMaxAge   *int      `json:"cache_max_age,omitempty" validate:"omitempty,gte=0"`
+	Passthrough   bool      `json:"passthrough,omitempty"`
+	QueryConflict string    `json:"query_conflict,omitempty" validate:"omitempty,oneof=destination request append"`
+	UTMTemplate   string    `json:"utm_template,omitempty"`
+	Rules         []Rule    `json:"rules,omitempty" validate:"omitempty,max=20,dive"`
+	Variants      []Variant `json:"variants,omitempty" validate:"omitempty,min=2,max=10,dive"`
+}
+
+// Rule is a conditional destination, the link URL is used if none of the rules matches
+type Rule struct {
+	Platform     string    `json:"platform,omitempty" validate:"omitempty,oneof=ios android windows macos linux other"`
+	Language     string    `json:"language,omitempty" validate:"omitempty,bcp47_language_tag"`
+	StartsAt     time.Time `json:"starts_at,omitempty"`
+	EndsAt       time.Time `json:"ends_at,omitempty" validate:"omitempty,gtfield=StartsAt"`
+	ReferrerHost string    `json:"referrer_host,omitempty" validate:"omitempty,hostname_rfc1123"`
+	Country      string    `json:"country,omitempty" validate:"omitempty,iso3166_1_alpha2"`
+	URL          string    `json:"url" validate:"required,url"`
+}
+
+// Variant is a destination receiving the share of traffic proportional to its weight
+type Variant struct {
+	URL    string `json:"url" validate:"required,url"`
+	Weight int    `json:"weight" validate:"required,min=1,max=1000"`
 }
 
 type Response struct {
 	response.Response
-	Alias string `json:"alias,omitempty"`
+	Alias     string `json:"alias,omitempty"`
+	Domain    string `json:"domain,omitempty"`
+	Workspace string `json:"workspace,omitempty"`
 }
 
 type URLSaver interface {
-	SaveURL(urlToSave string, alias string) error
-	GetURL(alias string) (string, error)
+	SaveURL(url models.URL) error
+	AliasExists(domain, alias string, fold storage.AliasFold) (bool, error)
+	UTMTemplate(name string) (models.UTMTemplate, error)
+	Workspace(name string) (models.Workspace, error)
+	IsWorkspaceMember(workspaceID, uid int64) (bool, error)
 }
 
 //go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=URLSaver
-func New(log *slog.Logger, urlSaver URLSaver, cfg *config.Config) http.HandlerFunc {
+func New(log *slog.Logger, urlSaver URLSaver, cfg *config.Config, policy *aliaspolicy.Policy) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.url.save.New"
 
-		log = log.With(
+		log := log.With(
 			slog.String("op", op),
 			slog.String("request_id", middleware.GetReqID(r.Context())),
 		)
 
-		err := auth.CheckPermission(r.Context())
+		user, err := auth.CurrentUser(r.Context())
 		if err != nil {
-			if errors.Is(err, auth.ErrConvert) {
-				log.Error("failed to convert", sl.Err(err))
-				render.JSON(w, r, response.Error("failed to convert token"))
-			} else if errors.Is(err, auth.ErrFailedAdminCheck) {
-				log.Error("failed to check if user is admin", sl.Err(err))
-				render.JSON(w, r, response.Error("failed to check if user is admin"))
-			} else if errors.Is(err, auth.ErrInvalidToken) {
-				log.Error("invalid token", sl.Err(err))
-				render.JSON(w, r, response.Error("invalid token"))
-			} else if errors.Is(err, auth.ErrPermissionDenied) {
-				log.Error("don't have permission to action", sl.Err(err))
-				render.JSON(w, r, response.Error("don't have permission to action"))
-			} else {
-				log.Error("internal error", sl.Err(err))
-				render.JSON(w, r, response.Error("internal error"))
-			}
+			log.Error("failed to get user", sl.Err(err))
+			response.Fail(w, r, response.ErrInternal)
 			return
 		}
 
@@ -67,7 +89,7 @@ func New(log *slog.Logger, urlSaver URLSaver, cfg *config.Config) http.HandlerFu
 		err = render.DecodeJSON(r.Body, &req)
 		if err != nil {
 			log.Error("failed to decode request body", sl.Err(err))
-			render.JSON(w, r, response.Error("failed to decode request"))
+			response.Fail(w, r, response.ErrDecodeRequest)
 			return
 		}
 
@@ -75,42 +97,171 @@ func New(log *slog.Logger, urlSaver URLSaver, cfg *config.Config) http.HandlerFu
 
 		if err = validator.New().Struct(req); err != nil {
 			log.Error("invalid request", sl.Err(err))
-			render.JSON(w, r, response.ValidationError(err.(validator.ValidationErrors)))
+			response.FailValidation(w, r, err.(validator.ValidationErrors))
 			return
 		}
 
+		// any user creates own links, only members share them in a workspace
+		var workspace *models.Workspace
+		if req.Workspace != "" {
+			ws, err := urlSaver.Workspace(req.Workspace)
+			if err != nil {
+				if errors.Is(err, storage.ErrWorkspaceNotFound) {
+					log.Info("workspace not found", slog.String("workspace", req.Workspace))
+					response.Fail(w, r, response.Unprocessable("workspace_not_found", "workspace not found"))
+				} else {
+					log.Error("failed to get workspace", sl.Err(err))
+					response.Fail(w, r, response.ErrInternal)
+				}
+				return
+			}
+			workspace = &ws
+		}
+		if workspace != nil && !user.IsAdmin {
+			member, err := urlSaver.IsWorkspaceMember(workspace.ID, user.UID)
+			if err != nil {
+				log.Error("failed to check workspace member", sl.Err(err))
+				response.Fail(w, r, response.ErrInternal)
+				return
+			}
+			if !member {
+				log.Info("user is not a workspace member", slog.Int64("uid", user.UID))
+				response.Fail(w, r, response.ErrPermissionDenied)
+				return
+			}
+		}
+
+		// links are saved on the domain of the request host unless another allowed one is given
+		linkDomain := domain.FromContext(r.Context())
+		if req.Domain != "" {
+			linkDomain = domain.Resolve(cfg.Domains, req.Domain)
+			if linkDomain == "" {
+				log.Info("domain is not allowed", slog.String("domain", req.Domain))
+				response.Fail(w, r, response.Unprocessable("domain_not_allowed", "domain is not allowed"))
+				return
+			}
+		}
+
 		alias := req.Alias
 		if alias == "" {
 			for {
 				alias = random.NewRandomString(cfg.AliasLength)
-				if _, err = urlSaver.GetURL(alias); err != nil {
+				if policy.ValidateWords(alias) != nil {
+					continue
+				}
+				exists, err := urlSaver.AliasExists(linkDomain, alias, policy.Fold())
+				if err != nil {
+					log.Error("failed to check alias", sl.Err(err))
+					response.Fail(w, r, response.ErrInternal)
+					return
+				}
+				if !exists {
 					break
 				}
 			}
 		} else {
-			if _, err = urlSaver.GetURL(alias); err == nil {
-				log.Error("alias already exist", sl.Err(err))
-				render.JSON(w, r, response.Error("alias already exist"))
+			if err = policy.Validate(alias); err != nil {
+				log.Info("alias violates policy", slog.String("alias", alias), sl.Err(err))
+				response.Fail(w, r, aliasError(err))
+				return
+			}
+			exists, err := urlSaver.AliasExists(linkDomain, alias, policy.Fold())
+			if err != nil {
+				log.Error("failed to check alias", sl.Err(err))
+				response.Fail(w, r, response.ErrInternal)
+				return
+			}
+			if exists {
+				log.Info("alias already exist", slog.String("alias", alias))
+				response.Fail(w, r, response.Conflict("alias_exists", "alias already exist"))
+				return
+			}
+		}
+
+		url := models.URL{
+			Domain:        linkDomain,
+			Alias:         alias,
+			OwnerUID:      user.UID,
+			Workspace:     workspace,
+			URL:           req.URL,
+			RedirectCode:  http.StatusFound,
+			CacheMaxAge:   -1,
+			Passthrough:   req.Passthrough,
+			QueryConflict: destination.ConflictDestination,
+		}
+		if req.RedirectCode != 0 {
+			url.RedirectCode = req.RedirectCode
+		}
+		if req.CacheMaxAge != nil {
+			url.CacheMaxAge = *req.CacheMaxAge
+		}
+		if req.QueryConflict != "" {
+			url.QueryConflict = req.QueryConflict
+		}
+		for _, rule := range req.Rules {
+			url.Rules = append(url.Rules, models.Rule{
+				Platform:     rule.Platform,
+				Language:     rule.Language,
+				StartsAt:     rule.StartsAt,
+				EndsAt:       rule.EndsAt,
+				ReferrerHost: rule.ReferrerHost,
+				Country:      rule.Country,
+				URL:          rule.URL,
+			})
+		}
+		for _, variant := range req.Variants {
+			url.Variants = append(url.Variants, models.Variant{
+				URL:    variant.URL,
+				Weight: variant.Weight,
+			})
+		}
+		if req.UTMTemplate != "" {
+			tmpl, err := urlSaver.UTMTemplate(req.UTMTemplate)
+			if err != nil {
+				if errors.Is(err, storage.ErrUTMTemplateNotFound) {
+					log.Info("utm template not found", slog.String("utm_template", req.UTMTemplate))
+					response.Fail(w, r, response.Unprocessable("utm_template_not_found", "utm template not found"))
+				} else {
+					log.Error("failed to get utm template", sl.Err(err))
+					response.Fail(w, r, response.ErrInternal)
+				}
 				return
 			}
+			url.UTMTemplate = &tmpl
 		}
 
-		err = urlSaver.SaveURL(req.URL, alias)
+		err = urlSaver.SaveURL(url)
 		if err != nil {
 			if errors.Is(err, storage.ErrURLExists) {
 				log.Info("url already exists", slog.String("url", req.URL))
-				render.JSON(w, r, response.Error("url already exists"))
+				response.Fail(w, r, response.Conflict("url_exists", "url already exists"))
 			} else {
 				log.Error("failed to add url", sl.Err(err))
-				render.JSON(w, r, response.Error("failed to add url"))
+				response.Fail(w, r, response.Internal("internal_error", "failed to add url"))
 			}
 			return
 		}
 
 		log.Info("url added")
 		render.JSON(w, r, Response{
-			Response: response.OK(),
-			Alias:    alias,
+			Response:  response.OK(),
+			Alias:     alias,
+			Domain:    linkDomain,
+			Workspace: req.Workspace,
 		})
 	}
 }
+
+// aliasError maps the policy violation to the API error
+func aliasError(err error) *response.APIError {
+	switch {
+	case errors.Is(err, aliaspolicy.ErrReserved):
+		return response.Unprocessable("alias_reserved", aliaspolicy.ErrReserved.Error())
+	case errors.Is(err, aliaspolicy.ErrProfane):
+		return response.Unprocessable("alias_not_allowed", aliaspolicy.ErrProfane.Error())
+	case errors.Is(err, aliaspolicy.ErrLength):
+		return response.Unprocessable("alias_invalid", aliaspolicy.ErrLength.Error())
+	default:
+		return response.Unprocessable("alias_invalid", aliaspolicy.ErrCharset.Error())
+	}
+}
@@ -7,11 +7,17 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
 
+	"url-shortener/domain/models"
 	"url-shortener/internal/config"
 	"url-shortener/internal/http-server/handlers/url/save"
 	"url-shortener/internal/http-server/handlers/url/save/mocks"
+	"url-shortener/internal/http-server/middleware/auth"
+	"url-shortener/internal/lib/aliaspolicy"
+	"url-shortener/internal/lib/api/response"
 	"url-shortener/internal/lib/logger/handlers/slogdiscard"
 	"url-shortener/internal/storage"
 
@@ -21,11 +27,25 @@ import (
 
 func TestNew(t *testing.T) {
 	cases := []struct {
-		name      string
-		alias     string
-		url       string
-		respError string
-		mockError error
+		name         string
+		alias        string
+		url          string
+		extra        string
+		domain       string
+		nonAdmin     bool
+		workspace    string
+		wsError      error
+		member       bool
+		redirectCode int
+		cacheMaxAge  int
+		utmTemplate  string
+		utmError     error
+		exists       bool
+		rules        int
+		variants     int
+		respError    string
+		status       int
+		mockError    error
 	}{
 		{
 			name:  "Success",
@@ -42,18 +62,234 @@ func TestNew(t *testing.T) {
 			url:       "",
 			alias:     "some_alias",
 			respError: "field URL is a required field",
+			status:    http.StatusUnprocessableEntity,
 		},
 		{
 			name:      "Invalid URL",
 			url:       "some invalid URL",
 			alias:     "some_alias",
 			respError: "field URL is not a valid URL",
+			status:    http.StatusUnprocessableEntity,
+		},
+		{
+			name:         "Redirect settings",
+			alias:        "test_alias",
+			url:          "https://google.com",
+			extra:        `, "redirect_code": 308, "cache_max_age": 3600`,
+			redirectCode: http.StatusPermanentRedirect,
+			cacheMaxAge:  3600,
+		},
+		{
+			name:      "Invalid redirect code",
+			alias:     "test_alias",
+			url:       "https://google.com",
+			extra:     `, "redirect_code": 200`,
+			respError: "field RedirectCode must be one of 301 302 303 307 308",
+			status:    http.StatusUnprocessableEntity,
+		},
+		{
+			name:      "Negative cache max age",
+			alias:     "test_alias",
+			url:       "https://google.com",
+			extra:     `, "cache_max_age": -5`,
+			respError: "field CacheMaxAge is not valid",
+			status:    http.StatusUnprocessableEntity,
+		},
+		{
+			name:      "Invalid query conflict",
+			alias:     "test_alias",
+			url:       "https://google.com",
+			extra:     `, "passthrough": true, "query_conflict": "merge"`,
+			respError: "field QueryConflict must be one of destination request append",
+			status:    http.StatusUnprocessableEntity,
+		},
+		{
+			name:  "Routing rules",
+			alias: "test_alias",
+			url:   "https://google.com",
+			extra: `, "rules": [` +
+				`{"platform": "ios", "url": "https://apps.apple.com/app"},` +
+				`{"language": "de", "starts_at": "2024-03-01T00:00:00Z", "ends_at": "2024-04-01T00:00:00Z", "url": "https://google.de"}]`,
+			rules: 2,
+		},
+		{
+			name:      "Invalid rule platform",
+			alias:     "test_alias",
+			url:       "https://google.com",
+			extra:     `, "rules": [{"platform": "symbian", "url": "https://nokia.com"}]`,
+			respError: "field Platform must be one of ios android windows macos linux other",
+			status:    http.StatusUnprocessableEntity,
+		},
+		{
+			name:      "Invalid rule window",
+			alias:     "test_alias",
+			url:       "https://google.com",
+			extra:     `, "rules": [{"starts_at": "2024-04-01T00:00:00Z", "ends_at": "2024-03-01T00:00:00Z", "url": "https://google.de"}]`,
+			respError: "field EndsAt is not valid",
+			status:    http.StatusUnprocessableEntity,
+		},
+		{
+			name:  "Country rule",
+			alias: "test_alias",
+			url:   "https://google.com",
+			extra: `, "rules": [{"country": "DE", "url": "https://google.de"}]`,
+			rules: 1,
+		},
+		{
+			name:      "Invalid rule country",
+			alias:     "test_alias",
+			url:       "https://google.com",
+			extra:     `, "rules": [{"country": "XX", "url": "https://google.de"}]`,
+			respError: "field Country is not valid",
+			status:    http.StatusUnprocessableEntity,
+		},
+		{
+			name:      "Rule without URL",
+			alias:     "test_alias",
+			url:       "https://google.com",
+			extra:     `, "rules": [{"platform": "android"}]`,
+			respError: "field URL is a required field",
+			status:    http.StatusUnprocessableEntity,
+		},
+		{
+			name:     "Variants",
+			alias:    "test_alias",
+			url:      "https://google.com",
+			extra:    `, "variants": [{"url": "https://a.google.com", "weight": 1}, {"url": "https://b.google.com", "weight": 3}]`,
+			variants: 2,
+		},
+		{
+			name:      "Single variant",
+			alias:     "test_alias",
+			url:       "https://google.com",
+			extra:     `, "variants": [{"url": "https://a.google.com", "weight": 1}]`,
+			respError: "field Variants is not valid",
+			status:    http.StatusUnprocessableEntity,
+		},
+		{
+			name:      "Variant without weight",
+			alias:     "test_alias",
+			url:       "https://google.com",
+			extra:     `, "variants": [{"url": "https://a.google.com", "weight": 1}, {"url": "https://b.google.com"}]`,
+			respError: "field Weight is a required field",
+			status:    http.StatusUnprocessableEntity,
+		},
+		{
+			name:        "UTM template",
+			alias:       "test_alias",
+			url:         "https://google.com",
+			extra:       `, "utm_template": "spring"`,
+			utmTemplate: "spring",
+		},
+		{
+			name:        "Unknown UTM template",
+			alias:       "test_alias",
+			url:         "https://google.com",
+			extra:       `, "utm_template": "autumn"`,
+			utmTemplate: "autumn",
+			utmError:    storage.ErrUTMTemplateNotFound,
+			respError:   "utm template not found",
+			status:      http.StatusUnprocessableEntity,
+		},
+		{
+			name:   "Custom domain",
+			alias:  "test_alias",
+			url:    "https://google.com",
+			extra:  `, "domain": "Go.Brand-A.com"`,
+			domain: "go.brand-a.com",
+		},
+		{
+			name:      "Domain not allowed",
+			alias:     "test_alias",
+			url:       "https://google.com",
+			extra:     `, "domain": "evil.com"`,
+			respError: "domain is not allowed",
+			status:    http.StatusUnprocessableEntity,
+		},
+		{
+			name:      "Workspace member",
+			alias:     "test_alias",
+			url:       "https://google.com",
+			extra:     `, "workspace": "marketing"`,
+			nonAdmin:  true,
+			workspace: "marketing",
+			member:    true,
+		},
+		{
+			name:      "Not a workspace member",
+			alias:     "test_alias",
+			url:       "https://google.com",
+			extra:     `, "workspace": "marketing"`,
+			nonAdmin:  true,
+			workspace: "marketing",
+			respError: "don't have permission to action",
+			status:    http.StatusForbidden,
+		},
+		{
+			name:     "Non-admin own link",
+			alias:    "test_alias",
+			url:      "https://google.com",
+			nonAdmin: true,
+		},
+		{
+			name:      "Unknown workspace",
+			alias:     "test_alias",
+			url:       "https://google.com",
+			extra:     `, "workspace": "sales"`,
+			workspace: "sales",
+			wsError:   storage.ErrWorkspaceNotFound,
+			respError: "workspace not found",
+			status:    http.StatusUnprocessableEntity,
+		},
+		{
+			name:      "Reserved alias",
+			alias:     "API",
+			url:       "https://google.com",
+			respError: "alias is reserved",
+			status:    http.StatusUnprocessableEntity,
+		},
+		{
+			name:      "Short alias",
+			alias:     "ab",
+			url:       "https://google.com",
+			respError: "alias has invalid length",
+			status:    http.StatusUnprocessableEntity,
+		},
+		{
+			name:      "Alias charset",
+			alias:     "test.alias",
+			url:       "https://google.com",
+			respError: "alias contains not allowed characters",
+			status:    http.StatusUnprocessableEntity,
+		},
+		{
+			name:      "Configured reserved alias",
+			alias:     "ADMIN",
+			url:       "https://google.com",
+			respError: "alias is reserved",
+			status:    http.StatusUnprocessableEntity,
+		},
+		{
+			name:      "Profane alias",
+			alias:     "d4rn_it",
+			url:       "https://google.com",
+			respError: "alias is not allowed",
+			status:    http.StatusUnprocessableEntity,
+		},
+		{
+			name:      "Alias exists",
+			alias:     "Test_Alias",
+			url:       "https://google.com",
+			exists:    true,
+			respError: "alias already exist",
+			status:    http.StatusConflict,
 		},
 		{
 			name:      "SaveURL Error",
 			alias:     "test_alias",
 			url:       "https://google.com",
 			respError: "failed to add url",
+			status:    http.StatusInternalServerError,
 			mockError: errors.New("unexpected error"),
 		},
 	}
@@ -62,17 +298,47 @@ func TestNew(t *testing.T) {
 		tc := tc
 		t.Run(tc.name, func(t *testing.T) {
 			urlSaverMock := mocks.NewURLSaver(t)
+			policy := newPolicy(t)
 
-			if tc.respError == "" || tc.mockError != nil {
-				if tc.alias == "" {
-					urlSaverMock.On("GetURL", mock.AnythingOfType("string")).
-						Return(mock.Anything, storage.ErrURLNotFound)
-				} else {
-					urlSaverMock.On("GetURL", mock.AnythingOfType("string")).
-						Return("", storage.ErrURLNotFound).
+			if tc.workspace != "" {
+				urlSaverMock.On("Workspace", tc.workspace).
+					Return(models.Workspace{ID: 3, Name: tc.workspace}, tc.wsError).
+					Once()
+				if tc.nonAdmin && tc.wsError == nil {
+					urlSaverMock.On("IsWorkspaceMember", int64(3), int64(1)).
+						Return(tc.member, nil).
 						Once()
 				}
-				urlSaverMock.On("SaveURL", tc.url, mock.AnythingOfType("string")).
+			}
+
+			if tc.utmTemplate != "" || tc.exists || tc.respError == "" || tc.mockError != nil {
+				call := urlSaverMock.On("AliasExists", tc.domain, mock.AnythingOfType("string"), policy.Fold()).
+					Return(tc.exists, nil)
+				if tc.alias != "" {
+					call.Once()
+				}
+			}
+			if tc.utmTemplate != "" {
+				urlSaverMock.On("UTMTemplate", tc.utmTemplate).
+					Return(models.UTMTemplate{ID: 1, Name: tc.utmTemplate}, tc.utmError).
+					Once()
+			}
+			if tc.utmError == nil && (tc.respError == "" || tc.mockError != nil) {
+				redirectCode, cacheMaxAge := tc.redirectCode, tc.cacheMaxAge
+				if redirectCode == 0 {
+					redirectCode, cacheMaxAge = http.StatusFound, -1
+				}
+				urlSaverMock.On("SaveURL", mock.MatchedBy(func(url models.URL) bool {
+					return url.URL == tc.url &&
+						url.Domain == tc.domain &&
+						url.OwnerUID == 1 &&
+						(tc.workspace == "") == (url.Workspace == nil) &&
+						url.RedirectCode == redirectCode &&
+						url.CacheMaxAge == cacheMaxAge &&
+						(tc.utmTemplate == "") == (url.UTMTemplate == nil) &&
+						len(url.Rules) == tc.rules &&
+						len(url.Variants) == tc.variants
+				})).
 					Return(tc.mockError).
 					Once()
 			}
@@ -80,23 +346,53 @@ func TestNew(t *testing.T) {
 			cfg := &config.Config{
 				HTTPServer: config.HTTPServer{
 					AliasLength: 6,
+					Domains:     []string{"go.brand-a.com"},
 				},
 			}
-			handler := save.New(slogdiscard.NewDiscardLogger(), urlSaverMock, cfg)
+			handler := save.New(slogdiscard.NewDiscardLogger(), urlSaverMock, cfg, policy)
 
-			input := fmt.Sprintf(`{"url": "%s", "alias": "%s"}`, tc.url, tc.alias)
+			input := fmt.Sprintf(`{"url": "%s", "alias": "%s"%s}`, tc.url, tc.alias, tc.extra)
 
 			req, err := http.NewRequest(http.MethodPost, "/url", bytes.NewReader([]byte(input)))
 			require.NoError(t, err)
+			req = req.WithContext(auth.WithUser(req.Context(), auth.User{UID: 1, IsAdmin: !tc.nonAdmin}))
 
 			rr := httptest.NewRecorder()
 			handler.ServeHTTP(rr, req)
-			require.Equal(t, rr.Code, http.StatusOK)
+			if tc.respError != "" {
+				require.Equal(t, tc.status, rr.Code)
+				require.Equal(t, response.ContentTypeProblem, rr.Header().Get("Content-Type"))
+
+				var problem response.Problem
+				require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &problem))
+				require.Equal(t, tc.respError, problem.Detail)
+				return
+			}
+			require.Equal(t, http.StatusOK, rr.Code)
 
 			body := rr.Body.String()
 			var resp save.Response
 			require.NoError(t, json.Unmarshal([]byte(body), &resp))
-			require.Equal(t, tc.respError, resp.Error)
 		})
 	}
 }
+
+func newPolicy(t *testing.T) *aliaspolicy.Policy {
+	t.Helper()
+
+	profanity := filepath.Join(t.TempDir(), "profanity.txt")
+	require.NoError(t, os.WriteFile(profanity, []byte("# test words\ndarn\n"), 0o600))
+
+	policy, err := aliaspolicy.New(config.AliasPolicy{
+		MinLength:           3,
+		MaxLength:           32,
+		Charset:             "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_",
+		Reserved:            []string{"admin"},
+		ProfanityPath:       profanity,
+		CaseInsensitive:     true,
+		NormalizeLookalikes: true,
+	})
+	require.NoError(t, err)
+
+	return policy
+}
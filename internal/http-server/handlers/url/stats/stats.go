@@ -0,0 +1,146 @@
+package stats
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"url-shortener/domain/models"
+	"url-shortener/internal/http-server/middleware/auth"
+	"url-shortener/internal/http-server/middleware/domain"
+	"url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/storage"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+type Variant struct {
+	URL    string `json:"url"`
+	Weight int    `json:"weight"`
+	Clicks int64  `json:"clicks"`
+}
+
+type Response struct {
+	response.Response
+	Alias     string           `json:"alias,omitempty"`
+	Clicks    int64            `json:"clicks"`
+	Countries map[string]int64 `json:"countries,omitempty"`
+	Variants  []Variant        `json:"variants,omitempty"`
+}
+
+type URLGetter interface {
+	URL(domain, alias string) (models.URL, error)
+	IsWorkspaceMember(workspaceID, uid int64) (bool, error)
+}
+
+// ClickCounter counts link visits by country, unknown locations are counted under empty key
+type ClickCounter interface {
+	ClicksByCountry(urlID int64) (map[string]int64, error)
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=URLGetter
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=ClickCounter
+func New(log *slog.Logger, urlGetter URLGetter, clickCounter ClickCounter, domains []string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.stats.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		user, err := auth.CurrentUser(r.Context())
+		if err != nil {
+			log.Error("failed to get user", sl.Err(err))
+			response.Fail(w, r, response.ErrInternal)
+			return
+		}
+
+		alias := chi.URLParam(r, "alias")
+		if alias == "" {
+			log.Error("alias is empty")
+			response.Fail(w, r, response.ErrInvalidRequest)
+			return
+		}
+
+		linkDomain, ok := domain.FromRequest(r, domains)
+		if !ok {
+			log.Info("domain is not allowed", slog.String("domain", r.URL.Query().Get(domain.QueryParam)))
+			response.Fail(w, r, response.Unprocessable("domain_not_allowed", "domain is not allowed"))
+			return
+		}
+
+		url, err := urlGetter.URL(linkDomain, alias)
+		if err != nil {
+			if errors.Is(err, storage.ErrURLNotFound) {
+				log.Info("url not found", slog.String("alias", alias))
+				response.Fail(w, r, response.NotFound("url_not_found", "url not found"))
+			} else {
+				log.Error("failed to get url", sl.Err(err))
+				response.Fail(w, r, response.ErrInternal)
+			}
+			return
+		}
+
+		// admins see stats of any link, users only of own links and links of their workspaces
+		if !user.IsAdmin {
+			allowed, err := canView(urlGetter, url, user)
+			if err != nil {
+				log.Error("failed to check workspace member", sl.Err(err))
+				response.Fail(w, r, response.ErrInternal)
+				return
+			}
+			if !allowed {
+				log.Info("user is neither the owner nor a workspace member", slog.Int64("uid", user.UID))
+				response.Fail(w, r, response.ErrPermissionDenied)
+				return
+			}
+		}
+
+		countries, err := clickCounter.ClicksByCountry(url.ID)
+		if err != nil {
+			log.Error("failed to count clicks", sl.Err(err))
+			response.Fail(w, r, response.ErrInternal)
+			return
+		}
+
+		resp := Response{
+			Response: response.OK(),
+			Alias:    url.Alias,
+		}
+		for country, clicks := range countries {
+			resp.Clicks += clicks
+			if country != "" {
+				if resp.Countries == nil {
+					resp.Countries = make(map[string]int64)
+				}
+				resp.Countries[country] = clicks
+			}
+		}
+		for _, variant := range url.Variants {
+			resp.Variants = append(resp.Variants, Variant{
+				URL:    variant.URL,
+				Weight: variant.Weight,
+				Clicks: variant.Clicks,
+			})
+		}
+
+		log.Info("url stats collected")
+		render.JSON(w, r, resp)
+	}
+}
+
+// canView checks if the user owns the link or is a member of the link workspace
+func canView(urlGetter URLGetter, url models.URL, user auth.User) (bool, error) {
+	if url.OwnerUID == user.UID {
+		return true, nil
+	}
+	if url.Workspace == nil {
+		return false, nil
+	}
+
+	return urlGetter.IsWorkspaceMember(url.Workspace.ID, user.UID)
+}
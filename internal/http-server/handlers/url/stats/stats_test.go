@@ -0,0 +1,209 @@
+package stats_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"url-shortener/domain/models"
+	"url-shortener/internal/http-server/handlers/url/stats"
+	"url-shortener/internal/http-server/handlers/url/stats/mocks"
+	"url-shortener/internal/http-server/middleware/auth"
+	"url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/storage"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	cases := []struct {
+		name       string
+		alias      string
+		query      string
+		domain     string
+		ownerUID   int64
+		workspace  *models.Workspace
+		isMember   *bool
+		isAdmin    bool
+		variants   []models.Variant
+		countries  map[string]int64
+		respError  string
+		status     int
+		mockError  error
+		countError error
+		clicks     int64
+	}{
+		{
+			name:  "Success",
+			alias: "test_alias",
+			variants: []models.Variant{
+				{ID: 1, URL: "https://a.example.com", Weight: 1, Clicks: 10},
+				{ID: 2, URL: "https://b.example.com", Weight: 3, Clicks: 31},
+			},
+			countries: map[string]int64{"DE": 20, "US": 15, "": 6},
+			clicks:    41,
+		},
+		{
+			name:      "Allowed domain",
+			alias:     "test_alias",
+			query:     "?domain=go.brand-a.com",
+			domain:    "go.brand-a.com",
+			countries: map[string]int64{},
+		},
+		{
+			name:      "Domain not allowed",
+			alias:     "test_alias",
+			query:     "?domain=evil.com",
+			respError: "domain is not allowed",
+			status:    http.StatusUnprocessableEntity,
+		},
+		{
+			name:      "Workspace member",
+			alias:     "test_alias",
+			ownerUID:  2,
+			workspace: &models.Workspace{ID: 7, Name: "marketing"},
+			isMember:  ptr(true),
+		},
+		{
+			name:     "Admin",
+			alias:    "test_alias",
+			ownerUID: 2,
+			isAdmin:  true,
+		},
+		{
+			name:      "Not a workspace member",
+			alias:     "test_alias",
+			ownerUID:  2,
+			workspace: &models.Workspace{ID: 7, Name: "marketing"},
+			isMember:  ptr(false),
+			respError: "don't have permission to action",
+			status:    http.StatusForbidden,
+		},
+		{
+			name:      "Link of another user",
+			alias:     "test_alias",
+			ownerUID:  2,
+			respError: "don't have permission to action",
+			status:    http.StatusForbidden,
+		},
+		{
+			name:       "Count Error",
+			alias:      "test_alias",
+			respError:  "internal error",
+			status:     http.StatusInternalServerError,
+			countError: errors.New("unexpected error"),
+		},
+		{
+			name:      "Not found",
+			alias:     "test_alias",
+			respError: "url not found",
+			status:    http.StatusNotFound,
+			mockError: storage.ErrURLNotFound,
+		},
+		{
+			name:      "URL Error",
+			alias:     "test_alias",
+			respError: "internal error",
+			status:    http.StatusInternalServerError,
+			mockError: errors.New("unexpected error"),
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ownerUID := tc.ownerUID
+			if ownerUID == 0 {
+				ownerUID = 1
+			}
+
+			urlGetterMock := mocks.NewURLGetter(t)
+			if tc.respError != "domain is not allowed" {
+				urlGetterMock.On("URL", tc.domain, tc.alias).
+					Return(models.URL{ID: 5, Alias: tc.alias, OwnerUID: ownerUID, Workspace: tc.workspace, Variants: tc.variants}, tc.mockError).
+					Once()
+			}
+			if tc.isMember != nil {
+				urlGetterMock.On("IsWorkspaceMember", int64(7), int64(1)).
+					Return(*tc.isMember, nil).
+					Once()
+			}
+
+			clickCounterMock := mocks.NewClickCounter(t)
+			if tc.mockError == nil && tc.respError != "domain is not allowed" && tc.respError != "don't have permission to action" {
+				clickCounterMock.On("ClicksByCountry", int64(5)).
+					Return(tc.countries, tc.countError).
+					Once()
+			}
+
+			r := chi.NewRouter()
+			r.Get("/{alias}/stats", stats.New(slogdiscard.NewDiscardLogger(), urlGetterMock, clickCounterMock, []string{"go.brand-a.com"}))
+
+			req, err := http.NewRequest(http.MethodGet, "/"+tc.alias+"/stats"+tc.query, nil)
+			require.NoError(t, err)
+			req = req.WithContext(auth.WithUser(req.Context(), auth.User{UID: 1, IsAdmin: tc.isAdmin}))
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+			if tc.respError != "" {
+				require.Equal(t, tc.status, rr.Code)
+				require.Equal(t, response.ContentTypeProblem, rr.Header().Get("Content-Type"))
+
+				var problem response.Problem
+				require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &problem))
+				require.Equal(t, tc.respError, problem.Detail)
+				return
+			}
+			require.Equal(t, http.StatusOK, rr.Code)
+
+			var resp stats.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			require.Equal(t, tc.clicks, resp.Clicks)
+			if tc.clicks > 0 {
+				require.Equal(t, map[string]int64{"DE": 20, "US": 15}, resp.Countries)
+			}
+			require.Len(t, resp.Variants, len(tc.variants))
+			for i, variant := range tc.variants {
+				require.Equal(t, variant.URL, resp.Variants[i].URL)
+				require.Equal(t, variant.Clicks, resp.Variants[i].Clicks)
+			}
+		})
+	}
+}
+
+func TestNew_DefaultScopes(t *testing.T) {
+	urlGetterMock := mocks.NewURLGetter(t)
+	urlGetterMock.On("URL", "", "test_alias").
+		Return(models.URL{ID: 5, Alias: "test_alias", OwnerUID: 1}, nil).
+		Once()
+
+	clickCounterMock := mocks.NewClickCounter(t)
+	clickCounterMock.On("ClicksByCountry", int64(5)).
+		Return(map[string]int64{"DE": 3}, nil).
+		Once()
+
+	log := slogdiscard.NewDiscardLogger()
+	r := chi.NewRouter()
+	r.With(auth.RequireScope(log, auth.ScopeAnalyticsRead)).
+		Get("/{alias}/stats", stats.New(log, urlGetterMock, clickCounterMock, nil))
+
+	// a token without scope claim gets the default scopes, its owner must see stats of own links
+	req, err := http.NewRequest(http.MethodGet, "/test_alias/stats", nil)
+	require.NoError(t, err)
+	req = req.WithContext(auth.WithUser(req.Context(), auth.User{UID: 1, Scopes: auth.DefaultScopes}))
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var resp stats.Response
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	require.Equal(t, int64(3), resp.Clicks)
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
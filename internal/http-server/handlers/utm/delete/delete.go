@@ -0,0 +1,56 @@
+package delete
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/storage"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+type UTMTemplateDeleter interface {
+	DeleteUTMTemplate(name string) error
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=UTMTemplateDeleter
+func New(log *slog.Logger, tmplDeleter UTMTemplateDeleter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.utm.delete.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		name := chi.URLParam(r, "name")
+		if name == "" {
+			log.Error("name is empty")
+			response.Fail(w, r, response.ErrInvalidRequest)
+			return
+		}
+
+		err := tmplDeleter.DeleteUTMTemplate(name)
+		if err != nil {
+			if errors.Is(err, storage.ErrUTMTemplateNotFound) {
+				log.Info("utm template not found", slog.String("name", name))
+			} else if errors.Is(err, storage.ErrUTMTemplateInUse) {
+				log.Info("utm template is in use", slog.String("name", name))
+				response.Fail(w, r, response.Conflict("utm_template_in_use", "utm template is used by links"))
+				return
+			} else {
+				log.Error("failed to delete utm template", sl.Err(err))
+				response.Fail(w, r, response.ErrInternal)
+				return
+			}
+		}
+
+		log.Info("utm template deleted")
+		render.JSON(w, r, response.OK())
+	}
+}
@@ -0,0 +1,83 @@
+package delete_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"url-shortener/internal/http-server/handlers/utm/delete"
+	"url-shortener/internal/http-server/handlers/utm/delete/mocks"
+	"url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/storage"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	cases := []struct {
+		name      string
+		tmplName  string
+		respError string
+		status    int
+		mockError error
+	}{
+		{
+			name:     "Success",
+			tmplName: "spring",
+		},
+		{
+			name:      "Not found",
+			tmplName:  "spring",
+			mockError: storage.ErrUTMTemplateNotFound,
+		},
+		{
+			name:      "In use",
+			tmplName:  "spring",
+			respError: "utm template is used by links",
+			status:    http.StatusConflict,
+			mockError: storage.ErrUTMTemplateInUse,
+		},
+		{
+			name:      "DeleteUTMTemplate Error",
+			tmplName:  "spring",
+			respError: "internal error",
+			status:    http.StatusInternalServerError,
+			mockError: errors.New("unexpected error"),
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			tmplDeleterMock := mocks.NewUTMTemplateDeleter(t)
+			tmplDeleterMock.On("DeleteUTMTemplate", tc.tmplName).
+				Return(tc.mockError).
+				Once()
+
+			r := chi.NewRouter()
+			r.Delete("/utm-templates/{name}", delete.New(slogdiscard.NewDiscardLogger(), tmplDeleterMock))
+
+			req, err := http.NewRequest(http.MethodDelete, "/utm-templates/"+tc.tmplName, nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+			if tc.respError != "" {
+				require.Equal(t, tc.status, rr.Code)
+				require.Equal(t, response.ContentTypeProblem, rr.Header().Get("Content-Type"))
+
+				var problem response.Problem
+				require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &problem))
+				require.Equal(t, tc.respError, problem.Detail)
+				return
+			}
+			require.Equal(t, http.StatusOK, rr.Code)
+
+			var resp response.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package list
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+
+	"url-shortener/domain/models"
+	"url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/sl"
+)
+
+type Template struct {
+	Name     string `json:"name"`
+	Source   string `json:"utm_source"`
+	Medium   string `json:"utm_medium"`
+	Campaign string `json:"utm_campaign"`
+	Term     string `json:"utm_term,omitempty"`
+	Content  string `json:"utm_content,omitempty"`
+}
+
+type Response struct {
+	response.Response
+	Templates []Template `json:"templates"`
+}
+
+type UTMTemplatesProvider interface {
+	UTMTemplates() ([]models.UTMTemplate, error)
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=UTMTemplatesProvider
+func New(log *slog.Logger, tmplProvider UTMTemplatesProvider) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.utm.list.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		templates, err := tmplProvider.UTMTemplates()
+		if err != nil {
+			log.Error("failed to get utm templates", sl.Err(err))
+			response.Fail(w, r, response.ErrInternal)
+			return
+		}
+
+		resp := Response{
+			Response:  response.OK(),
+			Templates: make([]Template, 0, len(templates)),
+		}
+		for _, tmpl := range templates {
+			resp.Templates = append(resp.Templates, Template{
+				Name:     tmpl.Name,
+				Source:   tmpl.Source,
+				Medium:   tmpl.Medium,
+				Campaign: tmpl.Campaign,
+				Term:     tmpl.Term,
+				Content:  tmpl.Content,
+			})
+		}
+
+		log.Info("utm templates listed", slog.Int("count", len(templates)))
+		render.JSON(w, r, resp)
+	}
+}
@@ -0,0 +1,81 @@
+package list_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"url-shortener/domain/models"
+	"url-shortener/internal/http-server/handlers/utm/list"
+	"url-shortener/internal/http-server/handlers/utm/list/mocks"
+	"url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	cases := []struct {
+		name      string
+		templates []models.UTMTemplate
+		respError string
+		status    int
+		mockError error
+	}{
+		{
+			name: "Success",
+			templates: []models.UTMTemplate{
+				{ID: 1, Name: "autumn", Source: "site", Medium: "banner", Campaign: "autumn_sale"},
+				{ID: 2, Name: "spring", Source: "newsletter", Medium: "email", Campaign: "spring_sale", Term: "shoes"},
+			},
+		},
+		{
+			name:      "Empty",
+			templates: nil,
+		},
+		{
+			name:      "UTMTemplates Error",
+			respError: "internal error",
+			status:    http.StatusInternalServerError,
+			mockError: errors.New("unexpected error"),
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			tmplProviderMock := mocks.NewUTMTemplatesProvider(t)
+			tmplProviderMock.On("UTMTemplates").
+				Return(tc.templates, tc.mockError).
+				Once()
+
+			handler := list.New(slogdiscard.NewDiscardLogger(), tmplProviderMock)
+
+			req, err := http.NewRequest(http.MethodGet, "/utm-templates", nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+			if tc.respError != "" {
+				require.Equal(t, tc.status, rr.Code)
+				require.Equal(t, response.ContentTypeProblem, rr.Header().Get("Content-Type"))
+
+				var problem response.Problem
+				require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &problem))
+				require.Equal(t, tc.respError, problem.Detail)
+				return
+			}
+			require.Equal(t, http.StatusOK, rr.Code)
+
+			var resp list.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			require.Len(t, resp.Templates, len(tc.templates))
+			for i, tmpl := range tc.templates {
+				require.Equal(t, tmpl.Name, resp.Templates[i].Name)
+				require.Equal(t, tmpl.Campaign, resp.Templates[i].Campaign)
+				require.Equal(t, tmpl.Term, resp.Templates[i].Term)
+			}
+		})
+	}
+}
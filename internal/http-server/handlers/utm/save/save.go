@@ -0,0 +1,79 @@
+package save
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+
+	"url-shortener/domain/models"
+	"url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/storage"
+)
+
+type Request struct {
+	Name     string `json:"name" validate:"required,max=64,alphanum"`
+	Source   string `json:"utm_source" validate:"required"`
+	Medium   string `json:"utm_medium" validate:"required"`
+	Campaign string `json:"utm_campaign" validate:"required"`
+	Term     string `json:"utm_term,omitempty"`
+	Content  string `json:"utm_content,omitempty"`
+}
+
+type UTMTemplateSaver interface {
+	SaveUTMTemplate(tmpl models.UTMTemplate) error
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=UTMTemplateSaver
+func New(log *slog.Logger, tmplSaver UTMTemplateSaver) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.utm.save.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		var req Request
+		err := render.DecodeJSON(r.Body, &req)
+		if err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+			response.Fail(w, r, response.ErrDecodeRequest)
+			return
+		}
+
+		log.Info("request body decoded", slog.Any("request", req))
+
+		if err = validator.New().Struct(req); err != nil {
+			log.Error("invalid request", sl.Err(err))
+			response.FailValidation(w, r, err.(validator.ValidationErrors))
+			return
+		}
+
+		err = tmplSaver.SaveUTMTemplate(models.UTMTemplate{
+			Name:     req.Name,
+			Source:   req.Source,
+			Medium:   req.Medium,
+			Campaign: req.Campaign,
+			Term:     req.Term,
+			Content:  req.Content,
+		})
+		if err != nil {
+			if errors.Is(err, storage.ErrUTMTemplateExists) {
+				log.Info("utm template already exists", slog.String("name", req.Name))
+				response.Fail(w, r, response.Conflict("utm_template_exists", "utm template already exists"))
+			} else {
+				log.Error("failed to add utm template", sl.Err(err))
+				response.Fail(w, r, response.Internal("internal_error", "failed to add utm template"))
+			}
+			return
+		}
+
+		log.Info("utm template added")
+		render.JSON(w, r, response.OK())
+	}
+}
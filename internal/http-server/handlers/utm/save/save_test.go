@@ -0,0 +1,98 @@
+package save_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"url-shortener/domain/models"
+	"url-shortener/internal/http-server/handlers/utm/save"
+	"url-shortener/internal/http-server/handlers/utm/save/mocks"
+	"url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/storage"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	cases := []struct {
+		name      string
+		input     string
+		respError string
+		status    int
+		mockError error
+	}{
+		{
+			name:  "Success",
+			input: `{"name": "spring", "utm_source": "newsletter", "utm_medium": "email", "utm_campaign": "spring_sale"}`,
+		},
+		{
+			name:      "Missing campaign",
+			input:     `{"name": "spring", "utm_source": "newsletter", "utm_medium": "email"}`,
+			respError: "field Campaign is a required field",
+			status:    http.StatusUnprocessableEntity,
+		},
+		{
+			name:      "Invalid name",
+			input:     `{"name": "spring/sale", "utm_source": "newsletter", "utm_medium": "email", "utm_campaign": "sale"}`,
+			respError: "field Name is not valid",
+			status:    http.StatusUnprocessableEntity,
+		},
+		{
+			name:      "Template exists",
+			input:     `{"name": "spring", "utm_source": "newsletter", "utm_medium": "email", "utm_campaign": "spring_sale"}`,
+			respError: "utm template already exists",
+			status:    http.StatusConflict,
+			mockError: storage.ErrUTMTemplateExists,
+		},
+		{
+			name:      "SaveUTMTemplate Error",
+			input:     `{"name": "spring", "utm_source": "newsletter", "utm_medium": "email", "utm_campaign": "spring_sale"}`,
+			respError: "failed to add utm template",
+			status:    http.StatusInternalServerError,
+			mockError: errors.New("unexpected error"),
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			tmplSaverMock := mocks.NewUTMTemplateSaver(t)
+
+			if tc.respError == "" || tc.mockError != nil {
+				tmplSaverMock.On("SaveUTMTemplate", mock.MatchedBy(func(tmpl models.UTMTemplate) bool {
+					return tmpl.Name == "spring" && tmpl.Campaign == "spring_sale"
+				})).
+					Return(tc.mockError).
+					Once()
+			}
+
+			handler := save.New(slogdiscard.NewDiscardLogger(), tmplSaverMock)
+
+			req, err := http.NewRequest(http.MethodPost, "/utm-templates", bytes.NewReader([]byte(tc.input)))
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+			if tc.respError != "" {
+				require.Equal(t, tc.status, rr.Code)
+				require.Equal(t, response.ContentTypeProblem, rr.Header().Get("Content-Type"))
+
+				var problem response.Problem
+				require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &problem))
+				require.Equal(t, tc.respError, problem.Detail)
+				return
+			}
+			require.Equal(t, http.StatusOK, rr.Code)
+
+			var resp response.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp), fmt.Sprintf("body: %s", rr.Body))
+		})
+	}
+}
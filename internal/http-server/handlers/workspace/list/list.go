@@ -0,0 +1,77 @@
+package list
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+
+	"url-shortener/domain/models"
+	"url-shortener/internal/http-server/middleware/auth"
+	"url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/sl"
+)
+
+type Workspace struct {
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Response struct {
+	response.Response
+	Workspaces []Workspace `json:"workspaces"`
+}
+
+type WorkspacesProvider interface {
+	Workspaces() ([]models.Workspace, error)
+	MemberWorkspaces(uid int64) ([]models.Workspace, error)
+}
+
+// New lists all workspaces to admins and the workspaces the user is a member of to others
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=WorkspacesProvider
+func New(log *slog.Logger, wsProvider WorkspacesProvider) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.workspace.list.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		user, err := auth.CurrentUser(r.Context())
+		if err != nil {
+			log.Error("failed to get user", sl.Err(err))
+			response.Fail(w, r, response.ErrInternal)
+			return
+		}
+
+		var workspaces []models.Workspace
+		if user.IsAdmin {
+			workspaces, err = wsProvider.Workspaces()
+		} else {
+			workspaces, err = wsProvider.MemberWorkspaces(user.UID)
+		}
+		if err != nil {
+			log.Error("failed to get workspaces", sl.Err(err))
+			response.Fail(w, r, response.ErrInternal)
+			return
+		}
+
+		resp := Response{
+			Response:   response.OK(),
+			Workspaces: make([]Workspace, 0, len(workspaces)),
+		}
+		for _, ws := range workspaces {
+			resp.Workspaces = append(resp.Workspaces, Workspace{
+				Name:      ws.Name,
+				CreatedAt: ws.CreatedAt,
+			})
+		}
+
+		log.Info("workspaces listed", slog.Int("count", len(workspaces)))
+		render.JSON(w, r, resp)
+	}
+}
@@ -0,0 +1,90 @@
+package list_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"url-shortener/domain/models"
+	"url-shortener/internal/http-server/handlers/workspace/list"
+	"url-shortener/internal/http-server/handlers/workspace/list/mocks"
+	"url-shortener/internal/http-server/middleware/auth"
+	"url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	cases := []struct {
+		name       string
+		isAdmin    bool
+		workspaces []models.Workspace
+		respError  string
+		status     int
+		mockError  error
+	}{
+		{
+			name:    "Admin sees all",
+			isAdmin: true,
+			workspaces: []models.Workspace{
+				{ID: 1, Name: "marketing"},
+				{ID: 2, Name: "sales"},
+			},
+		},
+		{
+			name:       "Member sees own",
+			workspaces: []models.Workspace{{ID: 1, Name: "marketing"}},
+		},
+		{
+			name:      "Workspaces Error",
+			isAdmin:   true,
+			respError: "internal error",
+			status:    http.StatusInternalServerError,
+			mockError: errors.New("unexpected error"),
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			wsProviderMock := mocks.NewWorkspacesProvider(t)
+			if tc.isAdmin {
+				wsProviderMock.On("Workspaces").
+					Return(tc.workspaces, tc.mockError).
+					Once()
+			} else {
+				wsProviderMock.On("MemberWorkspaces", int64(1)).
+					Return(tc.workspaces, tc.mockError).
+					Once()
+			}
+
+			handler := list.New(slogdiscard.NewDiscardLogger(), wsProviderMock)
+
+			req, err := http.NewRequest(http.MethodGet, "/workspaces", nil)
+			require.NoError(t, err)
+			req = req.WithContext(auth.WithUser(req.Context(), auth.User{UID: 1, IsAdmin: tc.isAdmin}))
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+			if tc.respError != "" {
+				require.Equal(t, tc.status, rr.Code)
+				require.Equal(t, response.ContentTypeProblem, rr.Header().Get("Content-Type"))
+
+				var problem response.Problem
+				require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &problem))
+				require.Equal(t, tc.respError, problem.Detail)
+				return
+			}
+			require.Equal(t, http.StatusOK, rr.Code)
+
+			var resp list.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			require.Len(t, resp.Workspaces, len(tc.workspaces))
+			for i, ws := range tc.workspaces {
+				require.Equal(t, ws.Name, resp.Workspaces[i].Name)
+			}
+		})
+	}
+}
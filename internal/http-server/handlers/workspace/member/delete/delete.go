@@ -0,0 +1,69 @@
+package delete
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+
+	"url-shortener/domain/models"
+	"url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/storage"
+)
+
+type MemberDeleter interface {
+	Workspace(name string) (models.Workspace, error)
+	DeleteWorkspaceMember(workspaceID, uid int64) error
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=MemberDeleter
+func New(log *slog.Logger, memberDeleter MemberDeleter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.workspace.member.delete.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		name := chi.URLParam(r, "name")
+		uid, err := strconv.ParseInt(chi.URLParam(r, "uid"), 10, 64)
+		if name == "" || err != nil {
+			log.Error("invalid workspace member", slog.String("name", name), slog.String("uid", chi.URLParam(r, "uid")))
+			response.Fail(w, r, response.ErrInvalidRequest)
+			return
+		}
+
+		ws, err := memberDeleter.Workspace(name)
+		if err != nil {
+			if errors.Is(err, storage.ErrWorkspaceNotFound) {
+				log.Info("workspace not found", slog.String("name", name))
+				response.Fail(w, r, response.NotFound("workspace_not_found", "workspace not found"))
+			} else {
+				log.Error("failed to get workspace", sl.Err(err))
+				response.Fail(w, r, response.ErrInternal)
+			}
+			return
+		}
+
+		err = memberDeleter.DeleteWorkspaceMember(ws.ID, uid)
+		if err != nil {
+			if errors.Is(err, storage.ErrWorkspaceMemberNotFound) {
+				log.Info("workspace member not found", slog.String("name", name), slog.Int64("uid", uid))
+				response.Fail(w, r, response.NotFound("workspace_member_not_found", "workspace member not found"))
+			} else {
+				log.Error("failed to delete workspace member", sl.Err(err))
+				response.Fail(w, r, response.ErrInternal)
+			}
+			return
+		}
+
+		log.Info("workspace member deleted", slog.String("name", name), slog.Int64("uid", uid))
+		render.JSON(w, r, response.OK())
+	}
+}
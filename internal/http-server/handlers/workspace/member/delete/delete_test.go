@@ -0,0 +1,100 @@
+package delete_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"url-shortener/domain/models"
+	"url-shortener/internal/http-server/handlers/workspace/member/delete"
+	"url-shortener/internal/http-server/handlers/workspace/member/delete/mocks"
+	"url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/storage"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	cases := []struct {
+		name      string
+		uid       string
+		wsError   error
+		respError string
+		status    int
+		mockError error
+	}{
+		{
+			name: "Success",
+			uid:  "42",
+		},
+		{
+			name:      "Invalid uid",
+			uid:       "abc",
+			respError: "invalid request",
+			status:    http.StatusBadRequest,
+		},
+		{
+			name:      "Unknown workspace",
+			uid:       "42",
+			wsError:   storage.ErrWorkspaceNotFound,
+			respError: "workspace not found",
+			status:    http.StatusNotFound,
+		},
+		{
+			name:      "Not a member",
+			uid:       "42",
+			respError: "workspace member not found",
+			status:    http.StatusNotFound,
+			mockError: storage.ErrWorkspaceMemberNotFound,
+		},
+		{
+			name:      "DeleteWorkspaceMember Error",
+			uid:       "42",
+			respError: "internal error",
+			status:    http.StatusInternalServerError,
+			mockError: errors.New("unexpected error"),
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			memberDeleterMock := mocks.NewMemberDeleter(t)
+			if tc.uid == "42" {
+				memberDeleterMock.On("Workspace", "marketing").
+					Return(models.Workspace{ID: 3, Name: "marketing"}, tc.wsError).
+					Once()
+				if tc.wsError == nil {
+					memberDeleterMock.On("DeleteWorkspaceMember", int64(3), int64(42)).
+						Return(tc.mockError).
+						Once()
+				}
+			}
+
+			r := chi.NewRouter()
+			r.Delete("/workspaces/{name}/members/{uid}", delete.New(slogdiscard.NewDiscardLogger(), memberDeleterMock))
+
+			req, err := http.NewRequest(http.MethodDelete, "/workspaces/marketing/members/"+tc.uid, nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+			if tc.respError != "" {
+				require.Equal(t, tc.status, rr.Code)
+				require.Equal(t, response.ContentTypeProblem, rr.Header().Get("Content-Type"))
+
+				var problem response.Problem
+				require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &problem))
+				require.Equal(t, tc.respError, problem.Detail)
+				return
+			}
+			require.Equal(t, http.StatusOK, rr.Code)
+
+			var resp response.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package save
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+
+	"url-shortener/domain/models"
+	"url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/storage"
+)
+
+type Request struct {
+	UID int64 `json:"uid" validate:"required,gt=0"`
+}
+
+type MemberSaver interface {
+	Workspace(name string) (models.Workspace, error)
+	AddWorkspaceMember(workspaceID, uid int64) error
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=MemberSaver
+func New(log *slog.Logger, memberSaver MemberSaver) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.workspace.member.save.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		name := chi.URLParam(r, "name")
+		if name == "" {
+			log.Error("name is empty")
+			response.Fail(w, r, response.ErrInvalidRequest)
+			return
+		}
+
+		var req Request
+		err := render.DecodeJSON(r.Body, &req)
+		if err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+			response.Fail(w, r, response.ErrDecodeRequest)
+			return
+		}
+
+		log.Info("request body decoded", slog.Any("request", req))
+
+		if err = validator.New().Struct(req); err != nil {
+			log.Error("invalid request", sl.Err(err))
+			response.FailValidation(w, r, err.(validator.ValidationErrors))
+			return
+		}
+
+		ws, err := memberSaver.Workspace(name)
+		if err != nil {
+			if errors.Is(err, storage.ErrWorkspaceNotFound) {
+				log.Info("workspace not found", slog.String("name", name))
+				response.Fail(w, r, response.NotFound("workspace_not_found", "workspace not found"))
+			} else {
+				log.Error("failed to get workspace", sl.Err(err))
+				response.Fail(w, r, response.ErrInternal)
+			}
+			return
+		}
+
+		if err = memberSaver.AddWorkspaceMember(ws.ID, req.UID); err != nil {
+			log.Error("failed to add workspace member", sl.Err(err))
+			response.Fail(w, r, response.Internal("internal_error", "failed to add workspace member"))
+			return
+		}
+
+		log.Info("workspace member added", slog.String("name", name), slog.Int64("uid", req.UID))
+		render.JSON(w, r, response.OK())
+	}
+}
@@ -0,0 +1,97 @@
+package save_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"url-shortener/domain/models"
+	"url-shortener/internal/http-server/handlers/workspace/member/save"
+	"url-shortener/internal/http-server/handlers/workspace/member/save/mocks"
+	"url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/storage"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	cases := []struct {
+		name      string
+		input     string
+		uid       int64
+		wsError   error
+		respError string
+		status    int
+		mockError error
+	}{
+		{
+			name:  "Success",
+			input: `{"uid": 42}`,
+			uid:   42,
+		},
+		{
+			name:      "Invalid uid",
+			input:     `{"uid": -1}`,
+			respError: "field UID is not valid",
+			status:    http.StatusUnprocessableEntity,
+		},
+		{
+			name:      "Unknown workspace",
+			input:     `{"uid": 42}`,
+			wsError:   storage.ErrWorkspaceNotFound,
+			respError: "workspace not found",
+			status:    http.StatusNotFound,
+		},
+		{
+			name:      "AddWorkspaceMember Error",
+			input:     `{"uid": 42}`,
+			uid:       42,
+			respError: "failed to add workspace member",
+			status:    http.StatusInternalServerError,
+			mockError: errors.New("unexpected error"),
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			memberSaverMock := mocks.NewMemberSaver(t)
+			if tc.uid != 0 || tc.wsError != nil {
+				memberSaverMock.On("Workspace", "marketing").
+					Return(models.Workspace{ID: 3, Name: "marketing"}, tc.wsError).
+					Once()
+			}
+			if tc.uid != 0 {
+				memberSaverMock.On("AddWorkspaceMember", int64(3), tc.uid).
+					Return(tc.mockError).
+					Once()
+			}
+
+			r := chi.NewRouter()
+			r.Post("/workspaces/{name}/members", save.New(slogdiscard.NewDiscardLogger(), memberSaverMock))
+
+			req, err := http.NewRequest(http.MethodPost, "/workspaces/marketing/members", bytes.NewReader([]byte(tc.input)))
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+			if tc.respError != "" {
+				require.Equal(t, tc.status, rr.Code)
+				require.Equal(t, response.ContentTypeProblem, rr.Header().Get("Content-Type"))
+
+				var problem response.Problem
+				require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &problem))
+				require.Equal(t, tc.respError, problem.Detail)
+				return
+			}
+			require.Equal(t, http.StatusOK, rr.Code)
+
+			var resp response.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		})
+	}
+}
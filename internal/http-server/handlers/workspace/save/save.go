@@ -0,0 +1,66 @@
+package save
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+
+	"url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/storage"
+)
+
+type Request struct {
+	Name string `json:"name" validate:"required,max=64,alphanum"`
+}
+
+type WorkspaceSaver interface {
+	SaveWorkspace(name string) error
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=WorkspaceSaver
+func New(log *slog.Logger, wsSaver WorkspaceSaver) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.workspace.save.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		var req Request
+		err := render.DecodeJSON(r.Body, &req)
+		if err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+			response.Fail(w, r, response.ErrDecodeRequest)
+			return
+		}
+
+		log.Info("request body decoded", slog.Any("request", req))
+
+		if err = validator.New().Struct(req); err != nil {
+			log.Error("invalid request", sl.Err(err))
+			response.FailValidation(w, r, err.(validator.ValidationErrors))
+			return
+		}
+
+		err = wsSaver.SaveWorkspace(req.Name)
+		if err != nil {
+			if errors.Is(err, storage.ErrWorkspaceExists) {
+				log.Info("workspace already exists", slog.String("name", req.Name))
+				response.Fail(w, r, response.Conflict("workspace_exists", "workspace already exists"))
+			} else {
+				log.Error("failed to add workspace", sl.Err(err))
+				response.Fail(w, r, response.Internal("internal_error", "failed to add workspace"))
+			}
+			return
+		}
+
+		log.Info("workspace added")
+		render.JSON(w, r, response.OK())
+	}
+}
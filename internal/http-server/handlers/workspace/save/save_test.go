@@ -0,0 +1,95 @@
+package save_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"url-shortener/internal/http-server/handlers/workspace/save"
+	"url-shortener/internal/http-server/handlers/workspace/save/mocks"
+	"url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/storage"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	cases := []struct {
+		name      string
+		input     string
+		wsName    string
+		respError string
+		status    int
+		mockError error
+	}{
+		{
+			name:   "Success",
+			input:  `{"name": "marketing"}`,
+			wsName: "marketing",
+		},
+		{
+			name:      "Empty name",
+			input:     `{}`,
+			respError: "field Name is a required field",
+			status:    http.StatusUnprocessableEntity,
+		},
+		{
+			name:      "Invalid name",
+			input:     `{"name": "sales/east"}`,
+			respError: "field Name is not valid",
+			status:    http.StatusUnprocessableEntity,
+		},
+		{
+			name:      "Already exists",
+			input:     `{"name": "marketing"}`,
+			wsName:    "marketing",
+			respError: "workspace already exists",
+			status:    http.StatusConflict,
+			mockError: storage.ErrWorkspaceExists,
+		},
+		{
+			name:      "SaveWorkspace Error",
+			input:     `{"name": "marketing"}`,
+			wsName:    "marketing",
+			respError: "failed to add workspace",
+			status:    http.StatusInternalServerError,
+			mockError: errors.New("unexpected error"),
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			wsSaverMock := mocks.NewWorkspaceSaver(t)
+			if tc.wsName != "" {
+				wsSaverMock.On("SaveWorkspace", tc.wsName).
+					Return(tc.mockError).
+					Once()
+			}
+
+			handler := save.New(slogdiscard.NewDiscardLogger(), wsSaverMock)
+
+			req, err := http.NewRequest(http.MethodPost, "/workspaces", bytes.NewReader([]byte(tc.input)))
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+			if tc.respError != "" {
+				require.Equal(t, tc.status, rr.Code)
+				require.Equal(t, response.ContentTypeProblem, rr.Header().Get("Content-Type"))
+
+				var problem response.Problem
+				require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &problem))
+				require.Equal(t, tc.respError, problem.Detail)
+				return
+			}
+			require.Equal(t, http.StatusOK, rr.Code)
+
+			var resp response.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		})
+	}
+}
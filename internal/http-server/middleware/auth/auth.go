@@ -7,9 +7,17 @@ import (
 	"log/slog"
 	"net/http"
 	"strings"
+	"time"
 
+	"github.com/go-chi/chi/v5/middleware"
+
+	"url-shortener/domain/models"
+	"url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/apikey"
+	"url-shortener/internal/lib/breaker"
 	"url-shortener/internal/lib/jwt"
 	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/storage"
 )
 
 var (
@@ -17,23 +25,109 @@ var (
 	ErrConvert          = errors.New("failed to convert")
 	ErrPermissionDenied = errors.New("don't have permission to action")
 	ErrFailedAdminCheck = errors.New("failed to check if user is admin")
+	ErrUnknownMode      = errors.New("unknown degraded mode")
+	ErrInvalidAPIKey    = errors.New("invalid api key")
+)
+
+// APIKeyHeader carries API keys, they are also accepted as bearer tokens
+const APIKeyHeader = "X-API-Key"
+
+// lastUsedPrecision limits updates of the last used time of API keys to one per key per minute
+const lastUsedPrecision = time.Minute
+
+// DegradedMode is how the admin status is resolved when the permission provider fails
+type DegradedMode string
+
+const (
+	// DegradedDeny fails requests to guarded routes
+	DegradedDeny DegradedMode = "deny"
+	// DegradedToken trusts the level claim of the token
+	DegradedToken DegradedMode = "token"
+)
+
+// ParseDegradedMode validates the mode name of the config
+func ParseDegradedMode(name string) (DegradedMode, error) {
+	switch mode := DegradedMode(name); mode {
+	case DegradedDeny, DegradedToken:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("%w: %s", ErrUnknownMode, name)
+	}
+}
+
+// Scope is a permission to a group of actions
+type Scope string
+
+const (
+	ScopeLinksRead     Scope = "links:read"
+	ScopeLinksWrite    Scope = "links:write"
+	ScopeLinksDelete   Scope = "links:delete"
+	ScopeAnalyticsRead Scope = "analytics:read"
+	// ScopeKeysManage allows to create, list and revoke own API keys, it is never granted to API keys
+	ScopeKeysManage Scope = "keys:manage"
+	// ScopeAdmin grants every other scope and access to links of all users
+	ScopeAdmin Scope = "admin"
 )
 
+// DefaultScopes are granted to users whose token has no scope claim
+var DefaultScopes = []Scope{ScopeLinksRead, ScopeLinksWrite, ScopeLinksDelete, ScopeAnalyticsRead, ScopeKeysManage}
+
 type Key string
 
 var (
-	authErrorKey = Key("authError")
-	isAdminKey   = Key("isAdmin")
+	authErrorKey   = Key("authError")
+	userKey        = Key("user")
+	adminLookupKey = Key("adminLookup")
 )
 
+// adminLookup resolves the admin status of the user of the request
+type adminLookup func(ctx context.Context) (bool, error)
+
+// User is the identity of the token bearer
+type User struct {
+	UID     int64
+	Email   string
+	IsAdmin bool
+	Scopes  []Scope
+	// APIKeyID is the key the request is authorized with, zero for tokens
+	APIKeyID int64
+}
+
+// HasScope reports whether the user is granted the scope, the admin scope grants all scopes
+func (u User) HasScope(scope Scope) bool {
+	for _, s := range u.Scopes {
+		if s == scope || s == ScopeAdmin {
+			return true
+		}
+	}
+
+	return false
+}
+
+// TokenParser verifies the bearer token and returns its claims
+type TokenParser interface {
+	Parse(ctx context.Context, raw string) (*jwt.Token, error)
+}
+
 type PermissionProvider interface {
 	IsAdmin(ctx context.Context, email string) (bool, error)
 }
 
+// APIKeyProvider finds API keys by the hash of their value
+type APIKeyProvider interface {
+	APIKey(hash []byte) (models.APIKey, error)
+	TouchAPIKey(id int64, usedAt time.Time) error
+}
+
+// New authorizes the bearer of a token or an API key. The admin status of token bearers is looked up
+// later by RequireScope so requests to routes without permissions don't reach SSO. API keys are limited
+// to their scopes, the admin scope of a key is granted only while its owner is still an admin
 func New(
 	log *slog.Logger,
-	userKey string,
+	tokenParser TokenParser,
 	permProvider PermissionProvider,
+	keyProvider APIKeyProvider,
+	degraded DegradedMode,
 ) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		log := log.With(
@@ -44,12 +138,48 @@ func New(
 
 		fn := func(w http.ResponseWriter, r *http.Request) {
 			jwtToken := extractBearerToken(r)
+
+			rawKey := r.Header.Get(APIKeyHeader)
+			if rawKey == "" && apikey.IsKey(jwtToken) {
+				rawKey = jwtToken
+			}
+			if rawKey != "" {
+				user, adminKey, err := authenticateKey(log, keyProvider, rawKey)
+				if err != nil {
+					log.Warn("failed to authenticate api key", sl.Err(err))
+
+					ctx := context.WithValue(r.Context(), authErrorKey, err)
+					next.ServeHTTP(w, r.WithContext(ctx))
+
+					return
+				}
+
+				log.Info("api key authorized",
+					slog.Int64("UID", user.UID),
+					slog.Int64("api_key_id", user.APIKeyID),
+					slog.Any("scopes", user.Scopes),
+				)
+
+				ctx := WithUser(r.Context(), user)
+				if adminKey {
+					// the owner may have lost the admin status since the key was issued, the lookup fails closed
+					// in any degraded mode since there is no token level to trust
+					lookup := adminLookup(func(ctx context.Context) (bool, error) {
+						return permProvider.IsAdmin(ctx, user.Email)
+					})
+					ctx = context.WithValue(ctx, adminLookupKey, lookup)
+				}
+
+				next.ServeHTTP(w, r.WithContext(ctx))
+				return
+			}
+
 			if jwtToken == "" {
 				next.ServeHTTP(w, r)
 				return
 			}
 
-			token, err := jwt.Parse(jwtToken, userKey)
+			token, err := tokenParser.Parse(r.Context(), jwtToken)
 			if err != nil {
 				log.Warn("failed to parse token", sl.Err(err))
 
@@ -66,21 +196,31 @@ func New(
 				slog.Bool("Is admin", token.Level > 1),
 			)
 
-			isAdmin, err := permProvider.IsAdmin(r.Context(), token.Email)
-			if err != nil {
-				log.Error("failed to check if user is admin", sl.Err(err))
+			user := User{UID: token.UID, Email: token.Email, Scopes: ParseScopes(token.Scopes)}
+			if len(token.Scopes) == 0 {
+				user.Scopes = DefaultScopes
+			}
 
-				ctx := context.WithValue(r.Context(), authErrorKey, true)
-				ctx = context.WithValue(ctx, isAdminKey, false)
-				next.ServeHTTP(w, r.WithContext(ctx))
+			entry.Info("user authorized", slog.Any("scopes", user.Scopes))
+
+			user.IsAdmin = user.HasScope(ScopeAdmin)
 
+			// tokens with a scope claim are narrowed to it, so only the admin scope of the claim makes them admin
+			if len(token.Scopes) > 0 {
+				next.ServeHTTP(w, r.WithContext(WithUser(r.Context(), user)))
 				return
 			}
 
-			entry.Info("user authorized")
+			lookup := adminLookup(func(ctx context.Context) (bool, error) {
+				isAdmin, err := permProvider.IsAdmin(ctx, token.Email)
+				if err != nil && degraded == DegradedToken {
+					entry.Warn("failed to check if user is admin, trusting token level", sl.Err(err))
+					return token.Level > 1, nil
+				}
+				return isAdmin, err
+			})
 
-			ctx := context.WithValue(r.Context(), isAdminKey, isAdmin)
-			ctx = context.WithValue(ctx, authErrorKey, false)
+			ctx := context.WithValue(WithUser(r.Context(), user), adminLookupKey, lookup)
 			next.ServeHTTP(w, r.WithContext(ctx))
 		}
 
@@ -88,25 +228,160 @@ func New(
 	}
 }
 
-func CheckPermission(ctx context.Context) error {
-	const op = "middleware.auth.CheckPermission"
+// authenticateKey finds the API key and records its use, the user gets the scopes of the key.
+// The admin scope is left out and reported separately, it is added back by the lookup of the owner
+func authenticateKey(log *slog.Logger, keyProvider APIKeyProvider, raw string) (User, bool, error) {
+	const op = "middleware.auth.authenticateKey"
 
-	isAdmin, ok := ctx.Value(isAdminKey).(bool)
-	if !ok {
-		return fmt.Errorf("%s: %w", op, ErrConvert)
+	if keyProvider == nil {
+		return User{}, false, fmt.Errorf("%s: %w", op, ErrInvalidAPIKey)
+	}
+
+	key, err := keyProvider.APIKey(apikey.Hash(raw))
+	if err != nil {
+		if errors.Is(err, storage.ErrAPIKeyNotFound) {
+			return User{}, false, fmt.Errorf("%s: %w", op, ErrInvalidAPIKey)
+		}
+		return User{}, false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	now := time.Now()
+	if !key.ExpiresAt.IsZero() && now.After(key.ExpiresAt) {
+		return User{}, false, fmt.Errorf("%s: %w: expired", op, ErrInvalidAPIKey)
 	}
-	if !isAdmin {
-		isErr, ok := ctx.Value(authErrorKey).(bool)
-		if !ok {
-			return fmt.Errorf("%s: %w", op, ErrConvert)
+
+	if now.Sub(key.LastUsedAt) >= lastUsedPrecision {
+		// the key is valid even if its use is not recorded
+		if err := keyProvider.TouchAPIKey(key.ID, now); err != nil {
+			log.Warn("failed to record api key use", slog.Int64("api_key_id", key.ID), sl.Err(err))
 		}
-		if isErr {
-			return fmt.Errorf("%s: %w", op, ErrFailedAdminCheck)
+	}
+
+	user := User{UID: key.UID, Email: key.Email, APIKeyID: key.ID}
+	adminKey := false
+	for _, scope := range ParseScopes(key.Scopes) {
+		if scope == ScopeAdmin {
+			adminKey = true
+			continue
 		}
-		return fmt.Errorf("%s: %w", op, ErrPermissionDenied)
+		user.Scopes = append(user.Scopes, scope)
+	}
+
+	return user, adminKey, nil
+}
+
+// RequireScope passes the request only if the authorized user is granted all the scopes
+func RequireScope(log *slog.Logger, scopes ...Scope) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			const op = "middleware.auth.RequireScope"
+
+			log := log.With(
+				slog.String("op", op),
+				slog.String("request_id", middleware.GetReqID(r.Context())),
+			)
+
+			ctx := r.Context()
+			user, err := CurrentUser(ctx)
+			if err == nil {
+				ctx, user, err = resolveAdmin(ctx, user)
+			}
+			if err == nil {
+				for _, scope := range scopes {
+					if !user.HasScope(scope) {
+						err = fmt.Errorf("%s: scope %s: %w", op, scope, ErrPermissionDenied)
+						break
+					}
+				}
+			}
+			if err != nil {
+				if errors.Is(err, ErrConvert) {
+					log.Error("failed to convert", sl.Err(err))
+					response.Fail(w, r, response.ErrUnauthenticated)
+				} else if errors.Is(err, breaker.ErrOpen) {
+					log.Error("sso is unavailable", sl.Err(err))
+					response.Fail(w, r, response.ServiceUnavailable("sso_unavailable", "sso is unavailable"))
+				} else if errors.Is(err, ErrFailedAdminCheck) {
+					log.Error("failed to check if user is admin", sl.Err(err))
+					response.Fail(w, r, response.Internal("admin_check_failed", "failed to check if user is admin"))
+				} else if errors.Is(err, ErrInvalidAPIKey) {
+					log.Error("invalid api key", sl.Err(err))
+					response.Fail(w, r, response.Unauthorized("invalid_api_key", "invalid api key"))
+				} else if errors.Is(err, ErrInvalidToken) {
+					log.Error("invalid token", sl.Err(err))
+					response.Fail(w, r, response.ErrInvalidToken)
+				} else if errors.Is(err, ErrPermissionDenied) {
+					log.Error("don't have permission to action", sl.Err(err))
+					response.Fail(w, r, response.ErrPermissionDenied)
+				} else {
+					log.Error("internal error", sl.Err(err))
+					response.Fail(w, r, response.ErrInternal)
+				}
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// resolveAdmin looks the admin status of the user up once per request and stores the updated user in ctx,
+// admins get the admin scope. Only users with the default scopes and owners of admin scoped keys are looked up
+func resolveAdmin(ctx context.Context, user User) (context.Context, User, error) {
+	lookup, ok := ctx.Value(adminLookupKey).(adminLookup)
+	if !ok || user.IsAdmin {
+		return ctx, user, nil
+	}
+
+	isAdmin, err := lookup(ctx)
+	if err != nil {
+		return ctx, user, fmt.Errorf("%w: %w", ErrFailedAdminCheck, err)
+	}
+
+	if isAdmin {
+		user.IsAdmin = true
+		// the scopes may be the shared default ones, so they are copied before the admin scope is added
+		user.Scopes = append(append(make([]Scope, 0, len(user.Scopes)+1), user.Scopes...), ScopeAdmin)
+	}
+	ctx = context.WithValue(WithUser(ctx, user), adminLookupKey, nil)
+
+	return ctx, user, nil
+}
+
+// ParseScopes converts scope names of a token, unknown names are skipped
+func ParseScopes(names []string) []Scope {
+	scopes := make([]Scope, 0, len(names))
+	for _, name := range names {
+		switch scope := Scope(name); scope {
+		case ScopeLinksRead, ScopeLinksWrite, ScopeLinksDelete, ScopeAnalyticsRead, ScopeKeysManage, ScopeAdmin:
+			scopes = append(scopes, scope)
+		}
+	}
+
+	return scopes
+}
+
+// WithUser returns a copy of ctx carrying the authorized user
+func WithUser(ctx context.Context, user User) context.Context {
+	return context.WithValue(ctx, userKey, user)
+}
+
+// CurrentUser returns the authorized user of the request
+func CurrentUser(ctx context.Context) (User, error) {
+	const op = "middleware.auth.CurrentUser"
+
+	if err, ok := ctx.Value(authErrorKey).(error); ok {
+		return User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	user, ok := ctx.Value(userKey).(User)
+	if !ok {
+		return User{}, fmt.Errorf("%s: %w", op, ErrConvert)
 	}
 
-	return nil
+	return user, nil
 }
 
 func extractBearerToken(r *http.Request) string {
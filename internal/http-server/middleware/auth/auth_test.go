@@ -0,0 +1,618 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	jwtlib "github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/domain/models"
+	"url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/apikey"
+	"url-shortener/internal/lib/breaker"
+	"url-shortener/internal/lib/jwt"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/storage"
+)
+
+const secret = "test-secret"
+
+type permProvider struct {
+	isAdmin bool
+	err     error
+	calls   int
+}
+
+func (p *permProvider) IsAdmin(_ context.Context, _ string) (bool, error) {
+	p.calls++
+	return p.isAdmin, p.err
+}
+
+// keyProvider serves keys by the hash of their value and records their use
+type keyProvider struct {
+	keys    map[string]models.APIKey
+	err     error
+	touched []int64
+}
+
+func (p *keyProvider) APIKey(hash []byte) (models.APIKey, error) {
+	if p.err != nil {
+		return models.APIKey{}, p.err
+	}
+	key, ok := p.keys[string(hash)]
+	if !ok {
+		return models.APIKey{}, storage.ErrAPIKeyNotFound
+	}
+	return key, nil
+}
+
+func (p *keyProvider) TouchAPIKey(id int64, _ time.Time) error {
+	p.touched = append(p.touched, id)
+	return nil
+}
+
+func signToken(t *testing.T, claims jwtlib.MapClaims) string {
+	t.Helper()
+
+	claims["uid"] = 1
+	claims["email"] = "user@example.com"
+	claims["exp"] = time.Now().Add(time.Hour).Unix()
+	if _, ok := claims["level"]; !ok {
+		claims["level"] = 1
+	}
+
+	token, err := jwtlib.NewWithClaims(jwtlib.SigningMethodHS256, claims).SignedString([]byte(secret))
+	require.NoError(t, err)
+
+	return token
+}
+
+func newVerifier(t *testing.T) *jwt.Verifier {
+	t.Helper()
+
+	return jwt.NewVerifier(jwt.Options{Secret: []byte(secret)})
+}
+
+func TestUser_HasScope(t *testing.T) {
+	user := User{Scopes: []Scope{ScopeLinksRead}}
+	assert.True(t, user.HasScope(ScopeLinksRead))
+	assert.False(t, user.HasScope(ScopeLinksWrite))
+
+	// the admin status alone doesn't widen the scopes
+	admin := User{IsAdmin: true, Scopes: []Scope{ScopeLinksRead}}
+	assert.False(t, admin.HasScope(ScopeLinksDelete))
+
+	scoped := User{Scopes: []Scope{ScopeAdmin}}
+	assert.True(t, scoped.HasScope(ScopeLinksDelete))
+}
+
+func TestParseScopes(t *testing.T) {
+	got := ParseScopes([]string{"links:read", "unknown", "analytics:read"})
+	assert.Equal(t, []Scope{ScopeLinksRead, ScopeAnalyticsRead}, got)
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name        string
+		token       string
+		perm        permProvider
+		wantScopes  []Scope
+		wantIsAdmin bool
+		wantErr     error
+	}{
+		{
+			name:       "default scopes",
+			token:      signToken(t, jwtlib.MapClaims{}),
+			wantScopes: DefaultScopes,
+		},
+		{
+			name:       "scope claim",
+			token:      signToken(t, jwtlib.MapClaims{"scope": "links:read analytics:read"}),
+			wantScopes: []Scope{ScopeLinksRead, ScopeAnalyticsRead},
+		},
+		{
+			name:       "scopes array claim",
+			token:      signToken(t, jwtlib.MapClaims{"scopes": []string{"links:write"}}),
+			wantScopes: []Scope{ScopeLinksWrite},
+		},
+		{
+			name:        "admin scope claim",
+			token:       signToken(t, jwtlib.MapClaims{"scope": "admin"}),
+			wantScopes:  []Scope{ScopeAdmin},
+			wantIsAdmin: true,
+		},
+		{
+			name:       "sso admin is not looked up",
+			token:      signToken(t, jwtlib.MapClaims{}),
+			perm:       permProvider{isAdmin: true},
+			wantScopes: DefaultScopes,
+		},
+		{
+			name:    "invalid token",
+			token:   "invalid",
+			wantErr: ErrInvalidToken,
+		},
+		{
+			name:    "malformed claims",
+			token:   signToken(t, jwtlib.MapClaims{"scopes": "links:read"}),
+			wantErr: ErrInvalidToken,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				user User
+				err  error
+			)
+			handler := New(slogdiscard.NewDiscardLogger(), newVerifier(t), &tt.perm, nil, DegradedDeny)(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					user, err = CurrentUser(r.Context())
+				}),
+			)
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			assert.Zero(t, tt.perm.calls)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, int64(1), user.UID)
+			assert.Equal(t, tt.wantScopes, user.Scopes)
+			assert.Equal(t, tt.wantIsAdmin, user.IsAdmin)
+		})
+	}
+}
+
+func TestNew_APIKey(t *testing.T) {
+	const (
+		writer  = "usk_writer"
+		admin   = "usk_admin"
+		expired = "usk_expired"
+		recent  = "usk_recent"
+	)
+	keys := &keyProvider{keys: map[string]models.APIKey{
+		string(apikey.Hash(writer)): {ID: 1, UID: 7, Email: "ci@example.com", Scopes: []string{"links:write"}},
+		string(apikey.Hash(admin)):  {ID: 2, UID: 7, Email: "ci@example.com", Scopes: []string{"admin"}},
+		string(apikey.Hash(expired)): {
+			ID: 3, UID: 7, Email: "ci@example.com", Scopes: []string{"links:write"},
+			ExpiresAt: time.Now().Add(-time.Minute),
+		},
+		string(apikey.Hash(recent)): {
+			ID: 4, UID: 7, Email: "ci@example.com", Scopes: []string{"links:read"},
+			LastUsedAt: time.Now().Add(-time.Second),
+		},
+	}}
+
+	tests := []struct {
+		name        string
+		header      string
+		value       string
+		keys        *keyProvider
+		noKeys      bool
+		wantScopes  []Scope
+		wantIsAdmin bool
+		wantTouched bool
+		wantErr     error
+	}{
+		{
+			name:        "api key header",
+			header:      APIKeyHeader,
+			value:       writer,
+			wantScopes:  []Scope{ScopeLinksWrite},
+			wantTouched: true,
+		},
+		{
+			name:        "bearer api key",
+			header:      "Authorization",
+			value:       "Bearer " + writer,
+			wantScopes:  []Scope{ScopeLinksWrite},
+			wantTouched: true,
+		},
+		{
+			// the admin scope is granted by RequireScope once the owner is confirmed to be an admin
+			name:        "admin scope",
+			header:      APIKeyHeader,
+			value:       admin,
+			wantTouched: true,
+		},
+		{
+			name:       "recent use is not recorded again",
+			header:     APIKeyHeader,
+			value:      recent,
+			wantScopes: []Scope{ScopeLinksRead},
+		},
+		{
+			name:    "unknown key",
+			header:  APIKeyHeader,
+			value:   "usk_unknown",
+			wantErr: ErrInvalidAPIKey,
+		},
+		{
+			name:    "expired key",
+			header:  APIKeyHeader,
+			value:   expired,
+			wantErr: ErrInvalidAPIKey,
+		},
+		{
+			name:    "keys are disabled",
+			header:  APIKeyHeader,
+			value:   writer,
+			noKeys:  true,
+			wantErr: ErrInvalidAPIKey,
+		},
+		{
+			name:    "storage error",
+			header:  APIKeyHeader,
+			value:   writer,
+			keys:    &keyProvider{err: errors.New("database is locked")},
+			wantErr: errors.New("database is locked"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := keys
+			if tt.keys != nil {
+				provider = tt.keys
+			}
+			provider.touched = nil
+
+			var kp APIKeyProvider = provider
+			if tt.noKeys {
+				kp = nil
+			}
+
+			var (
+				user User
+				err  error
+				perm permProvider
+			)
+			handler := New(slogdiscard.NewDiscardLogger(), newVerifier(t), &perm, kp, DegradedDeny)(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					user, err = CurrentUser(r.Context())
+				}),
+			)
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set(tt.header, tt.value)
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			assert.Zero(t, perm.calls)
+			if tt.wantErr != nil {
+				require.Error(t, err)
+				assert.ErrorContains(t, err, tt.wantErr.Error())
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, int64(7), user.UID)
+			assert.NotZero(t, user.APIKeyID)
+			assert.Equal(t, tt.wantScopes, user.Scopes)
+			assert.Equal(t, tt.wantIsAdmin, user.IsAdmin)
+			assert.Equal(t, tt.wantTouched, len(provider.touched) == 1)
+		})
+	}
+}
+
+func TestRequireScope_APIKeyIsNotPromoted(t *testing.T) {
+	keys := &keyProvider{keys: map[string]models.APIKey{
+		string(apikey.Hash("usk_writer")): {ID: 1, UID: 7, Email: "admin@example.com", Scopes: []string{"links:write"}},
+	}}
+	perm := permProvider{isAdmin: true}
+
+	called := false
+	log := slogdiscard.NewDiscardLogger()
+	handler := New(log, newVerifier(t), &perm, keys, DegradedDeny)(
+		RequireScope(log, ScopeAdmin)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			called = true
+		})),
+	)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(APIKeyHeader, "usk_writer")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	assert.False(t, called)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	assert.Zero(t, perm.calls, "the admin status of key owners is not looked up")
+}
+
+func TestRequireScope_AdminAPIKey(t *testing.T) {
+	keys := &keyProvider{keys: map[string]models.APIKey{
+		string(apikey.Hash("usk_admin")): {ID: 2, UID: 7, Email: "admin@example.com", Scopes: []string{"admin"}},
+	}}
+
+	tests := []struct {
+		name       string
+		perm       permProvider
+		degraded   DegradedMode
+		wantStatus int
+	}{
+		{
+			name:       "owner is admin",
+			perm:       permProvider{isAdmin: true},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "owner is no longer admin",
+			perm:       permProvider{isAdmin: false},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "lookup fails",
+			perm:       permProvider{err: errors.New("sso is down")},
+			degraded:   DegradedToken,
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			perm := tt.perm
+			degraded := tt.degraded
+			if degraded == "" {
+				degraded = DegradedDeny
+			}
+
+			called := false
+			log := slogdiscard.NewDiscardLogger()
+			handler := New(log, newVerifier(t), &perm, keys, degraded)(
+				RequireScope(log, ScopeLinksDelete)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					called = true
+				})),
+			)
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set(APIKeyHeader, "usk_admin")
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.wantStatus, rr.Code)
+			assert.Equal(t, tt.wantStatus == http.StatusOK, called)
+			assert.Equal(t, 1, perm.calls)
+		})
+	}
+}
+
+func TestRequireScope(t *testing.T) {
+	tests := []struct {
+		name      string
+		ctx       context.Context
+		scopes    []Scope
+		respError string
+		status    int
+	}{
+		{
+			name:   "granted",
+			ctx:    WithUser(context.Background(), User{UID: 1, Scopes: DefaultScopes}),
+			scopes: []Scope{ScopeLinksRead, ScopeLinksWrite},
+		},
+		{
+			name:   "admin",
+			ctx:    WithUser(context.Background(), User{UID: 1, IsAdmin: true, Scopes: []Scope{ScopeAdmin}}),
+			scopes: []Scope{ScopeAdmin},
+		},
+		{
+			name:      "missing scope",
+			ctx:       WithUser(context.Background(), User{UID: 1, Scopes: DefaultScopes}),
+			scopes:    []Scope{ScopeLinksRead, ScopeAdmin},
+			respError: "don't have permission to action",
+			status:    http.StatusForbidden,
+		},
+		{
+			name:      "anonymous",
+			ctx:       context.Background(),
+			scopes:    []Scope{ScopeLinksRead},
+			respError: "failed to convert token",
+			status:    http.StatusUnauthorized,
+		},
+		{
+			name:      "invalid token",
+			ctx:       context.WithValue(context.Background(), authErrorKey, ErrInvalidToken),
+			scopes:    []Scope{ScopeLinksRead},
+			respError: "invalid token",
+			status:    http.StatusUnauthorized,
+		},
+		{
+			name:      "invalid api key",
+			ctx:       context.WithValue(context.Background(), authErrorKey, ErrInvalidAPIKey),
+			scopes:    []Scope{ScopeLinksRead},
+			respError: "invalid api key",
+			status:    http.StatusUnauthorized,
+		},
+		{
+			name:      "failed admin check",
+			ctx:       context.WithValue(context.Background(), authErrorKey, ErrFailedAdminCheck),
+			scopes:    []Scope{ScopeLinksRead},
+			respError: "failed to check if user is admin",
+			status:    http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			called := false
+			handler := RequireScope(slogdiscard.NewDiscardLogger(), tt.scopes...)(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					called = true
+				}),
+			)
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(tt.ctx)
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.respError == "", called)
+			if tt.respError != "" {
+				assert.Equal(t, tt.status, rr.Code)
+
+				var problem response.Problem
+				require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &problem))
+				assert.Equal(t, tt.respError, problem.Detail)
+			}
+		})
+	}
+}
+
+func TestRequireScope_AdminLookup(t *testing.T) {
+	log := slogdiscard.NewDiscardLogger()
+
+	tests := []struct {
+		name        string
+		claims      jwtlib.MapClaims
+		perm        permProvider
+		degraded    DegradedMode
+		guards      int
+		scope       Scope
+		wantIsAdmin bool
+		wantCalls   int
+		status      int
+	}{
+		{
+			name:   "route without permissions",
+			claims: jwtlib.MapClaims{},
+			perm:   permProvider{isAdmin: true},
+			status: http.StatusOK,
+		},
+		{
+			name:        "sso admin",
+			claims:      jwtlib.MapClaims{},
+			perm:        permProvider{isAdmin: true},
+			guards:      1,
+			wantIsAdmin: true,
+			wantCalls:   1,
+			status:      http.StatusOK,
+		},
+		{
+			name:        "looked up once for nested guards",
+			claims:      jwtlib.MapClaims{},
+			perm:        permProvider{isAdmin: true},
+			guards:      2,
+			wantIsAdmin: true,
+			wantCalls:   1,
+			status:      http.StatusOK,
+		},
+		{
+			name:        "sso admin is granted admin scope",
+			claims:      jwtlib.MapClaims{},
+			perm:        permProvider{isAdmin: true},
+			guards:      1,
+			scope:       ScopeAdmin,
+			wantIsAdmin: true,
+			wantCalls:   1,
+			status:      http.StatusOK,
+		},
+		{
+			name:   "sso user is not granted admin scope",
+			claims: jwtlib.MapClaims{},
+			guards: 1,
+			scope:  ScopeAdmin,
+			// the lookup is made, but the user is denied
+			wantCalls: 1,
+			status:    http.StatusForbidden,
+		},
+		{
+			name:        "admin scope claim",
+			claims:      jwtlib.MapClaims{"scope": "admin"},
+			guards:      1,
+			wantIsAdmin: true,
+			status:      http.StatusOK,
+		},
+		{
+			name:   "narrowed token of sso admin",
+			claims: jwtlib.MapClaims{"scope": "links:read"},
+			perm:   permProvider{isAdmin: true},
+			guards: 1,
+			scope:  ScopeLinksDelete,
+			status: http.StatusForbidden,
+		},
+		{
+			name:   "narrowed token of sso admin is not admin",
+			claims: jwtlib.MapClaims{"scope": "links:read"},
+			perm:   permProvider{isAdmin: true},
+			guards: 1,
+			status: http.StatusOK,
+		},
+		{
+			name:      "failed admin check",
+			claims:    jwtlib.MapClaims{},
+			perm:      permProvider{err: errors.New("unavailable")},
+			guards:    1,
+			wantCalls: 1,
+			status:    http.StatusInternalServerError,
+		},
+		{
+			name:      "sso circuit open",
+			claims:    jwtlib.MapClaims{"level": 2},
+			perm:      permProvider{err: fmt.Errorf("sso: %w", breaker.ErrOpen)},
+			degraded:  DegradedDeny,
+			guards:    1,
+			wantCalls: 1,
+			status:    http.StatusServiceUnavailable,
+		},
+		{
+			name:        "degraded mode trusts admin level",
+			claims:      jwtlib.MapClaims{"level": 2},
+			perm:        permProvider{err: fmt.Errorf("sso: %w", breaker.ErrOpen)},
+			degraded:    DegradedToken,
+			guards:      1,
+			wantIsAdmin: true,
+			wantCalls:   1,
+			status:      http.StatusOK,
+		},
+		{
+			name:      "degraded mode trusts user level",
+			claims:    jwtlib.MapClaims{"level": 1},
+			perm:      permProvider{err: errors.New("unavailable")},
+			degraded:  DegradedToken,
+			guards:    1,
+			wantCalls: 1,
+			status:    http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var user User
+			var handler http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				user, _ = CurrentUser(r.Context())
+			})
+			scope := tt.scope
+			if scope == "" {
+				scope = ScopeLinksRead
+			}
+			for i := 0; i < tt.guards; i++ {
+				handler = RequireScope(log, scope)(handler)
+			}
+			handler = New(log, newVerifier(t), &tt.perm, nil, tt.degraded)(handler)
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Authorization", "Bearer "+signToken(t, tt.claims))
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.status, rr.Code)
+			assert.Equal(t, tt.wantCalls, tt.perm.calls)
+			if tt.status == http.StatusOK {
+				assert.Equal(t, tt.wantIsAdmin, user.IsAdmin)
+			}
+		})
+	}
+}
+
+func TestParseDegradedMode(t *testing.T) {
+	mode, err := ParseDegradedMode("token")
+	require.NoError(t, err)
+	assert.Equal(t, DegradedToken, mode)
+
+	_, err = ParseDegradedMode("allow")
+	assert.ErrorIs(t, err, ErrUnknownMode)
+}
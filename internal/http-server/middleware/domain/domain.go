@@ -0,0 +1,79 @@
+package domain
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+)
+
+type Key string
+
+var domainKey = Key("domain")
+
+// QueryParam selects the domain of the link in management requests
+const QueryParam = "domain"
+
+// New resolves the request Host to one of the configured short domains.
+// Requests to other hosts are served from the default domain
+func New(log *slog.Logger, domains []string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		log := log.With(
+			slog.String("component", "middleware/domain"),
+		)
+
+		log.Info("domain middleware enabled", slog.Any("domains", domains))
+
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(WithDomain(r.Context(), Resolve(domains, r.Host))))
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// Resolve returns the normalized host if it is one of domains, otherwise empty string of the default domain
+func Resolve(domains []string, host string) string {
+	host = Normalize(host)
+	for _, domain := range domains {
+		if Normalize(domain) == host {
+			return host
+		}
+	}
+
+	return ""
+}
+
+// FromRequest returns the domain named by the domain query parameter, so links of any allowed domain
+// are managed through the API host, and the domain of the request host without it.
+// ok is false if the parameter names a domain that is not allowed
+func FromRequest(r *http.Request, domains []string) (string, bool) {
+	name := r.URL.Query().Get(QueryParam)
+	if name == "" {
+		return FromContext(r.Context()), true
+	}
+
+	linkDomain := Resolve(domains, name)
+	return linkDomain, linkDomain != ""
+}
+
+// Normalize lowercases host and strips its port and trailing dot
+func Normalize(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(host)), ".")
+}
+
+// WithDomain returns a copy of ctx carrying the resolved domain
+func WithDomain(ctx context.Context, domain string) context.Context {
+	return context.WithValue(ctx, domainKey, domain)
+}
+
+// FromContext returns the domain of the request, empty string for the default domain
+func FromContext(ctx context.Context) string {
+	domain, _ := ctx.Value(domainKey).(string)
+	return domain
+}
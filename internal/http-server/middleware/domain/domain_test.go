@@ -0,0 +1,77 @@
+package domain
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+)
+
+func TestResolve(t *testing.T) {
+	domains := []string{"go.brand-a.com", "BRND.b"}
+
+	tests := []struct {
+		host string
+		want string
+	}{
+		{host: "go.brand-a.com", want: "go.brand-a.com"},
+		{host: "Go.Brand-A.com:8085", want: "go.brand-a.com"},
+		{host: "brnd.b.", want: "brnd.b"},
+		{host: "localhost:8085", want: ""},
+		{host: "brand-a.com", want: ""},
+		{host: "", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			assert.Equal(t, tt.want, Resolve(domains, tt.host))
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	var got string
+	handler := New(slogdiscard.NewDiscardLogger(), []string{"go.brand-a.com"})(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got = FromContext(r.Context())
+		}),
+	)
+
+	req := httptest.NewRequest(http.MethodGet, "http://go.brand-a.com/abc123", nil)
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Equal(t, "go.brand-a.com", got)
+
+	req = httptest.NewRequest(http.MethodGet, "http://localhost:8085/abc123", nil)
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Equal(t, "", got)
+}
+
+func TestFromRequest(t *testing.T) {
+	domains := []string{"go.brand-a.com"}
+
+	tests := []struct {
+		name   string
+		target string
+		host   string
+		want   string
+		wantOK bool
+	}{
+		{name: "request host", target: "/links/abc123", host: "go.brand-a.com", want: "go.brand-a.com", wantOK: true},
+		{name: "default domain", target: "/links/abc123", wantOK: true},
+		{name: "query parameter", target: "/links/abc123?domain=Go.Brand-A.com", want: "go.brand-a.com", wantOK: true},
+		{name: "query parameter wins", target: "/links/abc123?domain=go.brand-a.com", host: "other.com", want: "go.brand-a.com", wantOK: true},
+		{name: "not allowed", target: "/links/abc123?domain=evil.com", wantOK: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			req = req.WithContext(WithDomain(req.Context(), tt.host))
+
+			got, ok := FromRequest(req, domains)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package ready
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+
+	"url-shortener/internal/lib/api/response"
+)
+
+// RetryAfter is the number of seconds clients are asked to wait while the service is not ready
+const RetryAfter = "5"
+
+var ErrNotReady = response.ServiceUnavailable("sso_not_ready", "service is not registered in sso yet")
+
+// New answers 503 while ready reports false, it guards routes that can't work without SSO credentials
+func New(log *slog.Logger, ready func() bool) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		log := log.With(
+			slog.String("component", "middleware/ready"),
+		)
+
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			if !ready() {
+				log.Warn("service is not ready", slog.String("request_id", middleware.GetReqID(r.Context())))
+
+				w.Header().Set("Retry-After", RetryAfter)
+				response.Fail(w, r, ErrNotReady)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
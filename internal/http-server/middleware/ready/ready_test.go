@@ -0,0 +1,56 @@
+package ready
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+)
+
+func TestNew(t *testing.T) {
+	var isReady atomic.Bool
+
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	router := chi.NewRouter()
+	router.With(New(slogdiscard.NewDiscardLogger(), isReady.Load)).Route("/api/v1", func(r chi.Router) {
+		r.Get("/links", ok)
+	})
+	router.Get("/{alias}", ok)
+
+	tests := []struct {
+		name   string
+		ready  bool
+		path   string
+		status int
+	}{
+		{name: "redirect before registration", path: "/abc123", status: http.StatusOK},
+		{name: "management before registration", path: "/api/v1/links", status: http.StatusServiceUnavailable},
+		{name: "management after registration", ready: true, path: "/api/v1/links", status: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isReady.Store(tt.ready)
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			require.Equal(t, tt.status, rr.Code)
+			if tt.status != http.StatusServiceUnavailable {
+				return
+			}
+			assert.Equal(t, RetryAfter, rr.Header().Get("Retry-After"))
+
+			var problem response.Problem
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &problem))
+			assert.Equal(t, "sso_not_ready", problem.Code)
+		})
+	}
+}
@@ -0,0 +1,164 @@
+package aead
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// KeySize is the size of AES-256 keys
+const KeySize = 32
+
+// prefix marks sealed values, it is followed by the key id and the nonce with the ciphertext
+const prefix = "enc:v1:"
+
+var (
+	ErrNoKey         = errors.New("encryption key is not configured")
+	ErrInvalidKey    = errors.New("encryption key must be 32 bytes encoded in base64")
+	ErrNotSealed     = errors.New("value is not encrypted")
+	ErrMalformed     = errors.New("malformed encrypted value")
+	ErrUnknownKey    = errors.New("value is encrypted with unknown key")
+	ErrDecryptFailed = errors.New("failed to decrypt value")
+)
+
+// Key is an AES-256-GCM key, its id is derived from the key material so sealed values name the key they need
+type Key struct {
+	ID   string
+	aead cipher.AEAD
+}
+
+// ParseKey decodes a base64 encoded key
+func ParseKey(encoded string) (Key, error) {
+	const op = "lib.aead.ParseKey"
+
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil || len(raw) != KeySize {
+		return Key{}, fmt.Errorf("%s: %w", op, ErrInvalidKey)
+	}
+
+	block, err := aes.NewCipher(raw)
+	if err != nil {
+		return Key{}, fmt.Errorf("%s: %w", op, err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return Key{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	sum := sha256.Sum256(raw)
+
+	return Key{ID: hex.EncodeToString(sum[:4]), aead: gcm}, nil
+}
+
+// LoadKey parses the key value or, if it is empty, the content of the key file
+func LoadKey(value, path string) (Key, error) {
+	const op = "lib.aead.LoadKey"
+
+	if value == "" && path != "" {
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			return Key{}, fmt.Errorf("%s: %w", op, err)
+		}
+		value = string(raw)
+	}
+	if strings.TrimSpace(value) == "" {
+		return Key{}, fmt.Errorf("%s: %w", op, ErrNoKey)
+	}
+
+	return ParseKey(value)
+}
+
+// Keyring seals values with the primary key and opens values sealed with any of its keys,
+// the previous keys keep values readable while they are re-encrypted after rotation
+type Keyring struct {
+	primary Key
+	keys    map[string]Key
+}
+
+func NewKeyring(primary Key, previous ...Key) *Keyring {
+	keys := make(map[string]Key, len(previous)+1)
+	for _, k := range previous {
+		keys[k.ID] = k
+	}
+	keys[primary.ID] = primary
+
+	return &Keyring{primary: primary, keys: keys}
+}
+
+// PrimaryID is the id of the key new values are sealed with
+func (r *Keyring) PrimaryID() string {
+	return r.primary.ID
+}
+
+// Seal encrypts the plaintext, the additional data binds the value to its place and must be passed to Open
+func (r *Keyring) Seal(plaintext, ad []byte) (string, error) {
+	const op = "lib.aead.Keyring.Seal"
+
+	nonce := make([]byte, r.primary.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	sealed := r.primary.aead.Seal(nonce, nonce, plaintext, ad)
+
+	return prefix + r.primary.ID + ":" + base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// Open decrypts the value sealed by Seal with the same additional data
+func (r *Keyring) Open(value string, ad []byte) ([]byte, error) {
+	const op = "lib.aead.Keyring.Open"
+
+	id, payload, err := split(value)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	key, ok := r.keys[id]
+	if !ok {
+		return nil, fmt.Errorf("%s: %w: %s", op, ErrUnknownKey, id)
+	}
+
+	sealed, err := base64.RawStdEncoding.DecodeString(payload)
+	if err != nil || len(sealed) < key.aead.NonceSize() {
+		return nil, fmt.Errorf("%s: %w", op, ErrMalformed)
+	}
+
+	nonce, ciphertext := sealed[:key.aead.NonceSize()], sealed[key.aead.NonceSize():]
+	plaintext, err := key.aead.Open(nil, nonce, ciphertext, ad)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, ErrDecryptFailed)
+	}
+
+	return plaintext, nil
+}
+
+// IsSealed reports whether the value looks like the output of Seal
+func IsSealed(value string) bool {
+	return strings.HasPrefix(value, prefix)
+}
+
+// KeyID returns the id of the key the value is sealed with
+func KeyID(value string) (string, error) {
+	id, _, err := split(value)
+	return id, err
+}
+
+func split(value string) (string, string, error) {
+	if !IsSealed(value) {
+		return "", "", ErrNotSealed
+	}
+
+	id, payload, ok := strings.Cut(strings.TrimPrefix(value, prefix), ":")
+	if !ok || id == "" || payload == "" {
+		return "", "", ErrMalformed
+	}
+
+	return id, payload, nil
+}
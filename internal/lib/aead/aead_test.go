@@ -0,0 +1,129 @@
+package aead
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newKey(t *testing.T) (Key, string) {
+	t.Helper()
+
+	raw := make([]byte, KeySize)
+	_, err := rand.Read(raw)
+	require.NoError(t, err)
+
+	encoded := base64.StdEncoding.EncodeToString(raw)
+	key, err := ParseKey(encoded)
+	require.NoError(t, err)
+
+	return key, encoded
+}
+
+func TestKeyring_SealOpen(t *testing.T) {
+	oldKey, _ := newKey(t)
+	newKey, _ := newKey(t)
+
+	rotated := NewKeyring(newKey, oldKey)
+	previous := NewKeyring(oldKey)
+	ad := []byte("client:SSO:apiKey")
+
+	sealedOld, err := previous.Seal([]byte("secret"), ad)
+	require.NoError(t, err)
+	sealedNew, err := rotated.Seal([]byte("secret"), ad)
+	require.NoError(t, err)
+
+	assert.True(t, IsSealed(sealedNew))
+	assert.NotContains(t, sealedNew, "secret")
+	id, err := KeyID(sealedNew)
+	require.NoError(t, err)
+	assert.Equal(t, newKey.ID, id)
+
+	again, err := rotated.Seal([]byte("secret"), ad)
+	require.NoError(t, err)
+	assert.NotEqual(t, sealedNew, again, "nonce must be random")
+
+	// flip a character of the ciphertext
+	payload := []byte(sealedNew)
+	last := len(payload) - 2
+	if payload[last] == 'A' {
+		payload[last] = 'B'
+	} else {
+		payload[last] = 'A'
+	}
+
+	tests := []struct {
+		name    string
+		keyring *Keyring
+		value   string
+		ad      []byte
+		wantErr error
+	}{
+		{name: "current key", keyring: rotated, value: sealedNew, ad: ad},
+		{name: "previous key", keyring: rotated, value: sealedOld, ad: ad},
+		{name: "key is removed", keyring: NewKeyring(newKey), value: sealedOld, ad: ad, wantErr: ErrUnknownKey},
+		{name: "newer key is unknown", keyring: previous, value: sealedNew, ad: ad, wantErr: ErrUnknownKey},
+		{name: "other column", keyring: rotated, value: sealedNew, ad: []byte("client:SSO:userKey"), wantErr: ErrDecryptFailed},
+		{name: "tampered", keyring: rotated, value: string(payload), ad: ad, wantErr: ErrDecryptFailed},
+		{name: "plaintext", keyring: rotated, value: "secret", ad: ad, wantErr: ErrNotSealed},
+		{name: "no payload", keyring: rotated, value: prefix + newKey.ID, ad: ad, wantErr: ErrMalformed},
+		{name: "bad encoding", keyring: rotated, value: prefix + newKey.ID + ":***", ad: ad, wantErr: ErrMalformed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plaintext, err := tt.keyring.Open(tt.value, tt.ad)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "secret", string(plaintext))
+		})
+	}
+}
+
+func TestLoadKey(t *testing.T) {
+	key, encoded := newKey(t)
+	dir := t.TempDir()
+
+	path := filepath.Join(dir, "key")
+	require.NoError(t, os.WriteFile(path, []byte(encoded+"\n"), 0o600))
+	short := filepath.Join(dir, "short")
+	require.NoError(t, os.WriteFile(short, []byte(base64.StdEncoding.EncodeToString([]byte("short"))), 0o600))
+
+	tests := []struct {
+		name    string
+		value   string
+		path    string
+		wantErr error
+		wantAny bool
+	}{
+		{name: "value", value: encoded},
+		{name: "file", path: path},
+		{name: "value wins over file", value: encoded, path: short},
+		{name: "nothing", wantErr: ErrNoKey},
+		{name: "short key", path: short, wantErr: ErrInvalidKey},
+		{name: "not base64", value: strings.Repeat("*", 44), wantErr: ErrInvalidKey},
+		{name: "missing file", path: filepath.Join(dir, "missing"), wantAny: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := LoadKey(tt.value, tt.path)
+			switch {
+			case tt.wantErr != nil:
+				require.ErrorIs(t, err, tt.wantErr)
+			case tt.wantAny:
+				require.Error(t, err)
+			default:
+				require.NoError(t, err)
+				assert.Equal(t, key.ID, got.ID)
+			}
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package aliaspolicy
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"url-shortener/internal/config"
+	"url-shortener/internal/storage"
+)
+
+var (
+	ErrLength   = errors.New("alias has invalid length")
+	ErrCharset  = errors.New("alias contains not allowed characters")
+	ErrReserved = errors.New("alias is reserved")
+	ErrProfane  = errors.New("alias is not allowed")
+)
+
+// routePrefixes are the first path segments of service routes, links with such aliases could not be resolved
+var routePrefixes = map[string]struct{}{
+	"api": {},
+}
+
+// lookalikes maps characters that are easy to confuse to one of them,
+// the generator alphabet avoids the same characters
+var lookalikes = []string{"0", "o", "O", "o", "1", "l", "I", "l"}
+
+// skeletons maps lowercase lookalikes to one of them, reserved words are compared by it
+var skeletons = strings.NewReplacer("0", "o", "1", "l", "i", "l")
+
+// leet maps digits used in place of letters to the letters for the profanity check
+var leet = strings.NewReplacer("0", "o", "1", "i", "3", "e", "4", "a", "5", "s", "7", "t", "8", "b")
+
+// IsReserved reports whether the alias collides with a service route prefix
+func IsReserved(alias string) bool {
+	_, ok := routePrefixes[strings.ToLower(alias)]
+	return ok
+}
+
+// Policy restricts custom aliases and defines which aliases are considered equal
+type Policy struct {
+	minLength int
+	maxLength int
+	charset   map[rune]struct{}
+	reserved  map[string]struct{}
+	profanity []string
+	fold      storage.AliasFold
+}
+
+// New builds the policy, the profanity file has one word per line, lines starting with # are skipped
+func New(cfg config.AliasPolicy) (*Policy, error) {
+	const op = "lib.aliaspolicy.New"
+
+	p := &Policy{
+		minLength: cfg.MinLength,
+		maxLength: cfg.MaxLength,
+		charset:   make(map[rune]struct{}, len(cfg.Charset)),
+		reserved:  make(map[string]struct{}, len(cfg.Reserved)),
+		fold: storage.AliasFold{
+			IgnoreCase: cfg.CaseInsensitive,
+		},
+	}
+	if cfg.NormalizeLookalikes {
+		p.fold.Replace = lookalikes
+	}
+
+	for _, c := range cfg.Charset {
+		p.charset[c] = struct{}{}
+	}
+	for _, word := range cfg.Reserved {
+		p.reserved[p.skeleton(word)] = struct{}{}
+	}
+
+	if cfg.ProfanityPath != "" {
+		words, err := readWords(cfg.ProfanityPath)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		p.profanity = words
+	}
+
+	return p, nil
+}
+
+// Validate checks a custom alias against all the rules of the policy
+func (p *Policy) Validate(alias string) error {
+	const op = "lib.aliaspolicy.Validate"
+
+	if n := len([]rune(alias)); n < p.minLength || (p.maxLength > 0 && n > p.maxLength) {
+		return fmt.Errorf("%s: %w: must be from %d to %d characters", op, ErrLength, p.minLength, p.maxLength)
+	}
+	if len(p.charset) > 0 {
+		for _, c := range alias {
+			if _, ok := p.charset[c]; !ok {
+				return fmt.Errorf("%s: %w: %q", op, ErrCharset, c)
+			}
+		}
+	}
+
+	if err := p.ValidateWords(alias); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// ValidateWords checks only reserved and profane words, it is used for generated aliases
+func (p *Policy) ValidateWords(alias string) error {
+	const op = "lib.aliaspolicy.ValidateWords"
+
+	if IsReserved(alias) {
+		return fmt.Errorf("%s: %w", op, ErrReserved)
+	}
+	if _, ok := p.reserved[p.skeleton(alias)]; ok {
+		return fmt.Errorf("%s: %w", op, ErrReserved)
+	}
+
+	plain := leet.Replace(strings.ToLower(alias))
+	for _, word := range p.profanity {
+		if strings.Contains(plain, word) {
+			return fmt.Errorf("%s: %w", op, ErrProfane)
+		}
+	}
+
+	return nil
+}
+
+// Fold returns the rule by which aliases are compared for uniqueness
+func (p *Policy) Fold() storage.AliasFold {
+	return p.fold
+}
+
+// skeleton is the form reserved words are compared in regardless of the uniqueness options
+func (p *Policy) skeleton(word string) string {
+	return skeletons.Replace(strings.ToLower(word))
+}
+
+func readWords(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	var words []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		word := strings.ToLower(strings.TrimSpace(scanner.Text()))
+		if word == "" || strings.HasPrefix(word, "#") {
+			continue
+		}
+		words = append(words, word)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return words, nil
+}
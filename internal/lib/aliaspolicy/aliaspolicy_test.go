@@ -0,0 +1,114 @@
+package aliaspolicy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/config"
+)
+
+func TestIsReserved(t *testing.T) {
+	tests := []struct {
+		alias string
+		want  bool
+	}{
+		{alias: "api", want: true},
+		{alias: "API", want: true},
+		{alias: "apis", want: false},
+		{alias: "abc123", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.alias, func(t *testing.T) {
+			assert.Equal(t, tt.want, IsReserved(tt.alias))
+		})
+	}
+}
+
+func newPolicy(t *testing.T, cfg config.AliasPolicy) *Policy {
+	t.Helper()
+
+	profanity := filepath.Join(t.TempDir(), "profanity.txt")
+	require.NoError(t, os.WriteFile(profanity, []byte("# words aliases must not contain\nDarn\n\nheck\n"), 0o600))
+
+	cfg.MinLength = 3
+	cfg.MaxLength = 10
+	cfg.Charset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_"
+	cfg.Reserved = []string{"admin", "login"}
+	cfg.ProfanityPath = profanity
+
+	p, err := New(cfg)
+	require.NoError(t, err)
+
+	return p
+}
+
+func TestPolicy_Validate(t *testing.T) {
+	p := newPolicy(t, config.AliasPolicy{})
+
+	tests := []struct {
+		alias string
+		want  error
+	}{
+		{alias: "abc123", want: nil},
+		{alias: "my-link_1", want: nil},
+		{alias: "ab", want: ErrLength},
+		{alias: "abcdefghijk", want: ErrLength},
+		{alias: "my.link", want: ErrCharset},
+		{alias: "ссылка", want: ErrCharset},
+		{alias: "api", want: ErrReserved},
+		{alias: "Admin", want: ErrReserved},
+		{alias: "L0GIN", want: ErrReserved},
+		{alias: "darned", want: ErrProfane},
+		{alias: "oh-h3ck", want: ErrProfane},
+		{alias: "D4RN", want: ErrProfane},
+	}
+	for _, tt := range tests {
+		t.Run(tt.alias, func(t *testing.T) {
+			err := p.Validate(tt.alias)
+			if tt.want == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, tt.want)
+		})
+	}
+}
+
+func TestPolicy_ValidateWords(t *testing.T) {
+	p := newPolicy(t, config.AliasPolicy{})
+
+	// generated aliases are not limited by the length and charset of custom ones
+	assert.NoError(t, p.ValidateWords("a"))
+	assert.ErrorIs(t, p.ValidateWords("xdarnx"), ErrProfane)
+	assert.ErrorIs(t, p.ValidateWords("API"), ErrReserved)
+}
+
+func TestPolicy_Fold(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config.AliasPolicy
+		a, b string
+		same bool
+	}{
+		{name: "case sensitive", a: "Promo", b: "promo", same: false},
+		{name: "case insensitive", cfg: config.AliasPolicy{CaseInsensitive: true}, a: "Promo", b: "promo", same: true},
+		{name: "lookalikes kept", a: "g00d", b: "good", same: false},
+		{name: "lookalikes", cfg: config.AliasPolicy{NormalizeLookalikes: true}, a: "g00d", b: "good", same: true},
+		{name: "lookalikes and case", cfg: config.AliasPolicy{CaseInsensitive: true, NormalizeLookalikes: true}, a: "GOOD1", b: "g00dI", same: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fold := newPolicy(t, tt.cfg).Fold()
+			assert.Equal(t, tt.same, fold.Apply(tt.a) == fold.Apply(tt.b))
+		})
+	}
+}
+
+func TestNew_MissingProfanityFile(t *testing.T) {
+	_, err := New(config.AliasPolicy{ProfanityPath: filepath.Join(t.TempDir(), "missing.txt")})
+	assert.Error(t, err)
+}
@@ -0,0 +1,130 @@
+package response
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+)
+
+const ContentTypeProblem = "application/problem+json"
+
+// APIError is a failure of the request with the HTTP status and the stable machine-readable code
+type APIError struct {
+	Status  int
+	Code    string
+	Message string
+}
+
+func (e *APIError) Error() string {
+	return e.Message
+}
+
+// Problem is the RFC 7807 problem details body, extended with the error code and the request ID
+type Problem struct {
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Status    int    `json:"status"`
+	Detail    string `json:"detail,omitempty"`
+	Code      string `json:"code"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+var (
+	ErrInvalidRequest   = BadRequest("invalid_request", "invalid request")
+	ErrDecodeRequest    = BadRequest("malformed_body", "failed to decode request")
+	ErrUnauthenticated  = Unauthorized("unauthenticated", "failed to convert token")
+	ErrInvalidToken     = Unauthorized("invalid_token", "invalid token")
+	ErrPermissionDenied = Forbidden("permission_denied", "don't have permission to action")
+	ErrInternal         = Internal("internal_error", "internal error")
+)
+
+func BadRequest(code, msg string) *APIError {
+	return &APIError{Status: http.StatusBadRequest, Code: code, Message: msg}
+}
+
+func Unauthorized(code, msg string) *APIError {
+	return &APIError{Status: http.StatusUnauthorized, Code: code, Message: msg}
+}
+
+func Forbidden(code, msg string) *APIError {
+	return &APIError{Status: http.StatusForbidden, Code: code, Message: msg}
+}
+
+func NotFound(code, msg string) *APIError {
+	return &APIError{Status: http.StatusNotFound, Code: code, Message: msg}
+}
+
+func Conflict(code, msg string) *APIError {
+	return &APIError{Status: http.StatusConflict, Code: code, Message: msg}
+}
+
+func Gone(code, msg string) *APIError {
+	return &APIError{Status: http.StatusGone, Code: code, Message: msg}
+}
+
+func Unprocessable(code, msg string) *APIError {
+	return &APIError{Status: http.StatusUnprocessableEntity, Code: code, Message: msg}
+}
+
+func TooManyRequests(code, msg string) *APIError {
+	return &APIError{Status: http.StatusTooManyRequests, Code: code, Message: msg}
+}
+
+func Internal(code, msg string) *APIError {
+	return &APIError{Status: http.StatusInternalServerError, Code: code, Message: msg}
+}
+
+func ServiceUnavailable(code, msg string) *APIError {
+	return &APIError{Status: http.StatusServiceUnavailable, Code: code, Message: msg}
+}
+
+type legacyKey struct{}
+
+// Legacy makes Fail render errors in the Response format with 200 status for clients
+// written before problem details were introduced
+func Legacy(enabled bool) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if !enabled {
+			return next
+		}
+
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(WithLegacy(r.Context())))
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// WithLegacy returns a copy of ctx in which errors are rendered in the Response format
+func WithLegacy(ctx context.Context) context.Context {
+	return context.WithValue(ctx, legacyKey{}, true)
+}
+
+// Fail writes the error as problem details, or as Response in the legacy mode
+func Fail(w http.ResponseWriter, r *http.Request, err *APIError) {
+	if legacy, _ := r.Context().Value(legacyKey{}).(bool); legacy {
+		render.JSON(w, r, Error(err.Message))
+		return
+	}
+
+	w.Header().Set("Content-Type", ContentTypeProblem)
+	w.WriteHeader(err.Status)
+	_ = json.NewEncoder(w).Encode(Problem{
+		Type:      "about:blank",
+		Title:     http.StatusText(err.Status),
+		Status:    err.Status,
+		Detail:    err.Message,
+		Code:      err.Code,
+		RequestID: middleware.GetReqID(r.Context()),
+	})
+}
+
+// FailValidation writes validation errors of the request body with 422 status
+func FailValidation(w http.ResponseWriter, r *http.Request, errs validator.ValidationErrors) {
+	Fail(w, r, Unprocessable("validation_failed", ValidationError(errs).Error))
+}
@@ -0,0 +1,62 @@
+package response
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFail(t *testing.T) {
+	handler := middleware.RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Fail(w, r, NotFound("url_not_found", "url not found"))
+	}))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/abc123", nil))
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.Equal(t, ContentTypeProblem, rr.Header().Get("Content-Type"))
+
+	var problem Problem
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &problem))
+	assert.Equal(t, "about:blank", problem.Type)
+	assert.Equal(t, "Not Found", problem.Title)
+	assert.Equal(t, http.StatusNotFound, problem.Status)
+	assert.Equal(t, "url not found", problem.Detail)
+	assert.Equal(t, "url_not_found", problem.Code)
+	assert.NotEmpty(t, problem.RequestID)
+}
+
+func TestFail_Legacy(t *testing.T) {
+	tests := []struct {
+		name     string
+		legacy   bool
+		wantCode int
+	}{
+		{name: "problem details", legacy: false, wantCode: http.StatusConflict},
+		{name: "legacy", legacy: true, wantCode: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := Legacy(tt.legacy)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				Fail(w, r, Conflict("alias_exists", "alias already exist"))
+			}))
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/", nil))
+			assert.Equal(t, tt.wantCode, rr.Code)
+
+			if !tt.legacy {
+				return
+			}
+			var resp Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			assert.Equal(t, Error("alias already exist"), resp)
+		})
+	}
+}
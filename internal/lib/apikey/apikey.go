@@ -0,0 +1,43 @@
+package apikey
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+const (
+	// Prefix marks API keys so they are told apart from JWTs in the Authorization header
+	Prefix = "usk_"
+	// secretSize is the number of random bytes of a key
+	secretSize = 32
+	// visibleSize is the number of characters of the secret shown in key listings
+	visibleSize = 6
+)
+
+// Generate returns a new random key and its prefix shown to tell keys apart
+func Generate() (string, string, error) {
+	const op = "lib.apikey.Generate"
+
+	secret := make([]byte, secretSize)
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	raw := Prefix + base64.RawURLEncoding.EncodeToString(secret)
+
+	return raw, raw[:len(Prefix)+visibleSize], nil
+}
+
+// Hash is the form keys are stored and looked up in, the keys are random enough for a fast hash
+func Hash(raw string) []byte {
+	sum := sha256.Sum256([]byte(raw))
+	return sum[:]
+}
+
+// IsKey reports whether the credential looks like an API key rather than a JWT
+func IsKey(raw string) bool {
+	return strings.HasPrefix(raw, Prefix)
+}
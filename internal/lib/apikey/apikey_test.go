@@ -0,0 +1,32 @@
+package apikey
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerate(t *testing.T) {
+	raw, prefix, err := Generate()
+	require.NoError(t, err)
+
+	assert.True(t, IsKey(raw))
+	assert.True(t, len(raw) > 40)
+	assert.Equal(t, raw[:len(prefix)], prefix)
+	assert.Len(t, prefix, len(Prefix)+visibleSize)
+
+	other, _, err := Generate()
+	require.NoError(t, err)
+	assert.NotEqual(t, raw, other)
+
+	assert.Equal(t, Hash(raw), Hash(raw))
+	assert.NotEqual(t, Hash(raw), Hash(other))
+	assert.Len(t, Hash(raw), 32)
+}
+
+func TestIsKey(t *testing.T) {
+	assert.True(t, IsKey("usk_abc"))
+	assert.False(t, IsKey("eyJhbGciOiJIUzI1NiJ9.e30.sig"))
+	assert.False(t, IsKey(""))
+}
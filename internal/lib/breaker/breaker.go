@@ -0,0 +1,103 @@
+package breaker
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+var ErrOpen = errors.New("circuit breaker is open")
+
+type state int
+
+const (
+	stateClosed state = iota
+	stateOpen
+	stateHalfOpen
+)
+
+// Breaker stops calls after threshold consecutive failures. After the open timeout one probe call
+// is let through, its success closes the breaker and its failure opens it again
+type Breaker struct {
+	threshold   int
+	openTimeout time.Duration
+	now         func() time.Time
+
+	mu       sync.Mutex
+	state    state
+	failures int
+	openedAt time.Time
+}
+
+// New creates the breaker, a threshold below 1 disables it
+func New(threshold int, openTimeout time.Duration) *Breaker {
+	return &Breaker{
+		threshold:   threshold,
+		openTimeout: openTimeout,
+		now:         time.Now,
+	}
+}
+
+// Do calls fn unless the breaker is open, every error returned by fn counts as a failure
+func (b *Breaker) Do(fn func() error) error {
+	if !b.allow() {
+		return ErrOpen
+	}
+
+	err := fn()
+	b.done(err == nil)
+
+	return err
+}
+
+// Open reports whether calls are currently rejected
+func (b *Breaker) Open() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.state == stateOpen && b.now().Sub(b.openedAt) < b.openTimeout
+}
+
+func (b *Breaker) allow() bool {
+	if b.threshold < 1 {
+		return true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case stateOpen:
+		if b.now().Sub(b.openedAt) < b.openTimeout {
+			return false
+		}
+		b.state = stateHalfOpen
+		return true
+	case stateHalfOpen:
+		// the probe call is in flight
+		return false
+	default:
+		return true
+	}
+}
+
+func (b *Breaker) done(ok bool) {
+	if b.threshold < 1 {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if ok {
+		b.state = stateClosed
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+	if b.state == stateHalfOpen || b.failures >= b.threshold {
+		b.state = stateOpen
+		b.openedAt = b.now()
+	}
+}
@@ -0,0 +1,81 @@
+package breaker
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBreaker(t *testing.T) {
+	errFail := errors.New("unavailable")
+
+	b := New(2, time.Minute)
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	b.now = func() time.Time { return now }
+
+	steps := []struct {
+		name     string
+		advance  time.Duration
+		fail     bool
+		want     error
+		wantCall bool
+		wantOpen bool
+	}{
+		{name: "closed success", wantCall: true},
+		{name: "first failure", fail: true, want: errFail, wantCall: true},
+		{name: "success resets failures", wantCall: true},
+		{name: "failure", fail: true, want: errFail, wantCall: true},
+		{name: "threshold opens", fail: true, want: errFail, wantCall: true, wantOpen: true},
+		{name: "open rejects", want: ErrOpen, wantOpen: true},
+		{name: "still open", advance: 59 * time.Second, want: ErrOpen, wantOpen: true},
+		{name: "failed probe opens again", advance: time.Second, fail: true, want: errFail, wantCall: true, wantOpen: true},
+		{name: "open after probe", advance: 30 * time.Second, want: ErrOpen, wantOpen: true},
+		{name: "successful probe closes", advance: 30 * time.Second, wantCall: true},
+		{name: "closed again", wantCall: true},
+	}
+	for _, step := range steps {
+		now = now.Add(step.advance)
+
+		called := false
+		err := b.Do(func() error {
+			called = true
+			if step.fail {
+				return errFail
+			}
+			return nil
+		})
+
+		assert.ErrorIs(t, err, step.want, step.name)
+		if step.want == nil {
+			assert.NoError(t, err, step.name)
+		}
+		assert.Equal(t, step.wantCall, called, step.name)
+		assert.Equal(t, step.wantOpen, b.Open(), step.name)
+	}
+}
+
+func TestBreaker_SingleProbe(t *testing.T) {
+	b := New(1, time.Minute)
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	b.now = func() time.Time { return now }
+
+	_ = b.Do(func() error { return errors.New("unavailable") })
+	now = now.Add(time.Minute)
+
+	// calls made while the probe is in flight are rejected
+	err := b.Do(func() error {
+		assert.ErrorIs(t, b.Do(func() error { return nil }), ErrOpen)
+		return nil
+	})
+	assert.NoError(t, err)
+}
+
+func TestBreaker_Disabled(t *testing.T) {
+	b := New(0, time.Minute)
+	for i := 0; i < 10; i++ {
+		_ = b.Do(func() error { return errors.New("unavailable") })
+	}
+	assert.NoError(t, b.Do(func() error { return nil }))
+}
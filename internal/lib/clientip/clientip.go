@@ -0,0 +1,77 @@
+package clientip
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// ParseNetworks parses trusted proxy addresses given as CIDRs or single IPs
+func ParseNetworks(values []string) ([]*net.IPNet, error) {
+	const op = "lib.clientip.ParseNetworks"
+
+	networks := make([]*net.IPNet, 0, len(values))
+	for _, value := range values {
+		if !strings.Contains(value, "/") {
+			ip := net.ParseIP(value)
+			if ip == nil {
+				return nil, fmt.Errorf("%s: invalid address %q", op, value)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, network, err := net.ParseCIDR(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		networks = append(networks, network)
+	}
+
+	return networks, nil
+}
+
+// FromRequest returns the visitor address. X-Forwarded-For is taken into account
+// only if the request came from a trusted proxy, the header is walked from the nearest hop
+// and the first address not belonging to trusted proxies is returned
+func FromRequest(r *http.Request, trusted []*net.IPNet) net.IP {
+	remote := parseIP(r.RemoteAddr)
+	if remote == nil || !contains(trusted, remote) {
+		return remote
+	}
+
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		ip := parseIP(strings.TrimSpace(hops[i]))
+		if ip == nil {
+			break
+		}
+		if !contains(trusted, ip) {
+			return ip
+		}
+		remote = ip
+	}
+
+	return remote
+}
+
+func parseIP(addr string) net.IP {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		addr = host
+	}
+	return net.ParseIP(addr)
+}
+
+func contains(networks []*net.IPNet, ip net.IP) bool {
+	for _, network := range networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,83 @@
+package clientip
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseNetworks(t *testing.T) {
+	networks, err := ParseNetworks([]string{"10.0.0.0/8", "127.0.0.1", "::1"})
+	require.NoError(t, err)
+	require.Len(t, networks, 3)
+	assert.Equal(t, "127.0.0.1/32", networks[1].String())
+	assert.Equal(t, "::1/128", networks[2].String())
+
+	_, err = ParseNetworks([]string{"not an ip"})
+	assert.Error(t, err)
+}
+
+func TestFromRequest(t *testing.T) {
+	trusted, err := ParseNetworks([]string{"10.0.0.0/8"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name         string
+		remoteAddr   string
+		forwardedFor string
+		trustProxies bool
+		want         string
+	}{
+		{
+			name:       "direct visitor",
+			remoteAddr: "203.0.113.7:51234",
+			want:       "203.0.113.7",
+		},
+		{
+			name:         "header from untrusted peer is ignored",
+			remoteAddr:   "203.0.113.7:51234",
+			forwardedFor: "198.51.100.1",
+			trustProxies: true,
+			want:         "203.0.113.7",
+		},
+		{
+			name:         "trusted proxy",
+			remoteAddr:   "10.0.0.2:443",
+			forwardedFor: "198.51.100.1",
+			trustProxies: true,
+			want:         "198.51.100.1",
+		},
+		{
+			name:         "spoofed hops before the proxy chain",
+			remoteAddr:   "10.0.0.2:443",
+			forwardedFor: "1.2.3.4, 198.51.100.1, 10.0.0.3",
+			trustProxies: true,
+			want:         "198.51.100.1",
+		},
+		{
+			name:         "no trusted proxies configured",
+			remoteAddr:   "10.0.0.2:443",
+			forwardedFor: "198.51.100.1",
+			want:         "10.0.0.2",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = tt.remoteAddr
+			if tt.forwardedFor != "" {
+				r.Header.Set("X-Forwarded-For", tt.forwardedFor)
+			}
+
+			var networks = trusted
+			if !tt.trustProxies {
+				networks = nil
+			}
+
+			assert.Equal(t, tt.want, FromRequest(r, networks).String())
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package destination
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Rules of resolving query parameters present both in the stored destination and in the request
+const (
+	// ConflictDestination keeps the value of the stored destination
+	ConflictDestination = "destination"
+	// ConflictRequest replaces the stored value with the one from the request
+	ConflictRequest = "request"
+	// ConflictAppend keeps both values, the stored one goes first
+	ConflictAppend = "append"
+)
+
+var (
+	ErrUnknownConflict = errors.New("unknown query conflict rule")
+	ErrInvalidSuffix   = errors.New("invalid path suffix")
+)
+
+// Passthrough merges the incoming query into the destination URL and appends
+// the trailing path to its path. Conflicting query keys are resolved by conflict rule.
+// The suffix is the unescaped request path, its dot segments are rejected
+// so that it can't leave the destination path
+func Passthrough(dest string, query url.Values, suffix string, conflict string) (string, error) {
+	const op = "lib.destination.Passthrough"
+
+	u, err := url.Parse(dest)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	if suffix != "" {
+		segments := strings.Split(suffix, "/")
+		for i, segment := range segments {
+			if segment == "." || segment == ".." {
+				return "", fmt.Errorf("%s: %w: %s", op, ErrInvalidSuffix, suffix)
+			}
+			segments[i] = url.PathEscape(segment)
+		}
+		u = u.JoinPath(segments...)
+	}
+
+	if len(query) == 0 {
+		return u.String(), nil
+	}
+
+	merged := u.Query()
+	for key, values := range query {
+		_, exists := merged[key]
+		switch {
+		case !exists:
+			merged[key] = values
+		case conflict == ConflictDestination || conflict == "":
+		case conflict == ConflictRequest:
+			merged[key] = values
+		case conflict == ConflictAppend:
+			merged[key] = append(merged[key], values...)
+		default:
+			return "", fmt.Errorf("%s: %w: %s", op, ErrUnknownConflict, conflict)
+		}
+	}
+	u.RawQuery = merged.Encode()
+
+	return u.String(), nil
+}
+
+// WithParams adds params to the destination URL query.
+// Keys already present in the destination are left untouched
+func WithParams(dest string, params url.Values) (string, error) {
+	const op = "lib.destination.WithParams"
+
+	u, err := url.Parse(dest)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	query := u.Query()
+	for key, values := range params {
+		if _, exists := query[key]; !exists {
+			query[key] = values
+		}
+	}
+	u.RawQuery = query.Encode()
+
+	return u.String(), nil
+}
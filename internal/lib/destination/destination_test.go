@@ -0,0 +1,137 @@
+package destination
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPassthrough(t *testing.T) {
+	tests := []struct {
+		name     string
+		dest     string
+		query    url.Values
+		suffix   string
+		conflict string
+		want     string
+		wantErr  error
+	}{
+		{
+			name: "nothing to pass",
+			dest: "https://example.com/page?a=1",
+			want: "https://example.com/page?a=1",
+		},
+		{
+			name:  "new query key",
+			dest:  "https://example.com/page?a=1",
+			query: url.Values{"ref": {"newsletter"}},
+			want:  "https://example.com/page?a=1&ref=newsletter",
+		},
+		{
+			name:   "path suffix",
+			dest:   "https://example.com/docs/",
+			suffix: "extra/path",
+			want:   "https://example.com/docs/extra/path",
+		},
+		{
+			name:   "path suffix without destination path",
+			dest:   "https://example.com",
+			suffix: "extra",
+			want:   "https://example.com/extra",
+		},
+		{
+			name:    "path suffix with parent segments",
+			dest:    "https://example.com/docs",
+			suffix:  "../../admin",
+			wantErr: ErrInvalidSuffix,
+		},
+		{
+			name:    "path suffix with inner parent segment",
+			dest:    "https://example.com/docs/",
+			suffix:  "guide/../../admin",
+			wantErr: ErrInvalidSuffix,
+		},
+		{
+			name:    "path suffix with dot segment",
+			dest:    "https://example.com/docs",
+			suffix:  "./admin",
+			wantErr: ErrInvalidSuffix,
+		},
+		{
+			name:   "path suffix is escaped",
+			dest:   "https://example.com/docs",
+			suffix: "a b/%2e%2e/..x",
+			want:   "https://example.com/docs/a%20b/%252e%252e/..x",
+		},
+		{
+			name:     "conflict keeps destination",
+			dest:     "https://example.com/?a=1",
+			query:    url.Values{"a": {"2"}},
+			conflict: ConflictDestination,
+			want:     "https://example.com/?a=1",
+		},
+		{
+			name:     "conflict takes request",
+			dest:     "https://example.com/?a=1",
+			query:    url.Values{"a": {"2"}},
+			conflict: ConflictRequest,
+			want:     "https://example.com/?a=2",
+		},
+		{
+			name:     "conflict appends",
+			dest:     "https://example.com/?a=1",
+			query:    url.Values{"a": {"2"}},
+			conflict: ConflictAppend,
+			want:     "https://example.com/?a=1&a=2",
+		},
+		{
+			name:     "unknown conflict",
+			dest:     "https://example.com/?a=1",
+			query:    url.Values{"a": {"2"}},
+			conflict: "other",
+			wantErr:  ErrUnknownConflict,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Passthrough(tt.dest, tt.query, tt.suffix, tt.conflict)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestWithParams(t *testing.T) {
+	tests := []struct {
+		name   string
+		dest   string
+		params url.Values
+		want   string
+	}{
+		{
+			name:   "adds params",
+			dest:   "https://example.com/page",
+			params: url.Values{"utm_source": {"mail"}, "utm_medium": {"email"}},
+			want:   "https://example.com/page?utm_medium=email&utm_source=mail",
+		},
+		{
+			name:   "keeps destination values",
+			dest:   "https://example.com/page?utm_source=site&a=1",
+			params: url.Values{"utm_source": {"mail"}, "utm_campaign": {"spring"}},
+			want:   "https://example.com/page?a=1&utm_campaign=spring&utm_source=site",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := WithParams(tt.dest, tt.params)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package geoip
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sort"
+	"strings"
+)
+
+type ipRange struct {
+	start   net.IP
+	end     net.IP
+	country string
+}
+
+// CSV is an IP range database loaded from CSV file.
+// Rows are either "network,country" with network in CIDR notation
+// or "start_ip,end_ip,country", lines starting with # and a header row are skipped
+type CSV struct {
+	ranges []ipRange
+}
+
+// OpenCSV reads CSV IP range database from disk
+func OpenCSV(path string) (*CSV, error) {
+	const op = "lib.geoip.OpenCSV"
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() { _ = f.Close() }()
+
+	db, err := NewCSV(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return db, nil
+}
+
+// NewCSV parses CSV IP range database
+func NewCSV(r io.Reader) (*CSV, error) {
+	const op = "lib.geoip.NewCSV"
+
+	reader := csv.NewReader(r)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	var ranges []ipRange
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		rng, err := parseRange(record)
+		if err != nil {
+			if line == 1 {
+				// header row
+				continue
+			}
+			pos, _ := reader.FieldPos(0)
+			return nil, fmt.Errorf("%s: line %d: %w", op, pos, err)
+		}
+		ranges = append(ranges, rng)
+	}
+
+	sort.Slice(ranges, func(i, j int) bool {
+		return bytes.Compare(ranges[i].start, ranges[j].start) < 0
+	})
+
+	return &CSV{ranges: ranges}, nil
+}
+
+// Country returns ISO 3166-1 alpha-2 country code of the address or empty string if it is unknown
+func (db *CSV) Country(ip net.IP) (string, error) {
+	key := ip.To16()
+	if key == nil {
+		return "", nil
+	}
+
+	// the last range starting not after the address
+	i := sort.Search(len(db.ranges), func(i int) bool {
+		return bytes.Compare(db.ranges[i].start, key) > 0
+	}) - 1
+	if i < 0 || bytes.Compare(key, db.ranges[i].end) > 0 {
+		return "", nil
+	}
+
+	return db.ranges[i].country, nil
+}
+
+func parseRange(record []string) (ipRange, error) {
+	switch len(record) {
+	case 2:
+		_, network, err := net.ParseCIDR(strings.TrimSpace(record[0]))
+		if err != nil {
+			return ipRange{}, err
+		}
+		start := network.IP.To16()
+		end := make(net.IP, net.IPv6len)
+		mask := network.Mask
+		if len(mask) == net.IPv4len {
+			mask = append(net.CIDRMask(96, 128)[:12], mask...)
+		}
+		for i := range end {
+			end[i] = start[i] | ^mask[i]
+		}
+		return ipRange{start: start, end: end, country: country(record[1])}, nil
+	case 3:
+		start := net.ParseIP(strings.TrimSpace(record[0]))
+		end := net.ParseIP(strings.TrimSpace(record[1]))
+		if start == nil || end == nil {
+			return ipRange{}, fmt.Errorf("invalid range %s-%s", record[0], record[1])
+		}
+		if bytes.Compare(start.To16(), end.To16()) > 0 {
+			return ipRange{}, fmt.Errorf("range start %s is after its end %s", record[0], record[1])
+		}
+		return ipRange{start: start.To16(), end: end.To16(), country: country(record[2])}, nil
+	default:
+		return ipRange{}, fmt.Errorf("unexpected number of fields %d", len(record))
+	}
+}
+
+func country(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
@@ -0,0 +1,81 @@
+package geoip
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/lib/clientip"
+)
+
+const testCSV = `# test database
+start_ip,end_ip,country
+1.0.0.0,1.0.0.255,au
+5.0.0.0,5.255.255.255,DE
+2a00:1450::,2a00:1450:ffff:ffff:ffff:ffff:ffff:ffff,US
+`
+
+func TestCSV_Country(t *testing.T) {
+	db, err := NewCSV(strings.NewReader(testCSV))
+	require.NoError(t, err)
+
+	tests := []struct {
+		ip   string
+		want string
+	}{
+		{ip: "1.0.0.0", want: "AU"},
+		{ip: "1.0.0.255", want: "AU"},
+		{ip: "1.0.1.0", want: ""},
+		{ip: "5.4.3.2", want: "DE"},
+		{ip: "4.255.255.255", want: ""},
+		{ip: "2a00:1450:4001::1", want: "US"},
+		{ip: "::1", want: ""},
+	}
+	for _, tt := range tests {
+		country, err := db.Country(net.ParseIP(tt.ip))
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, country, tt.ip)
+	}
+}
+
+func TestCSV_Networks(t *testing.T) {
+	db, err := NewCSV(strings.NewReader("81.2.69.0/24,GB\n2001:218::/32,JP\n"))
+	require.NoError(t, err)
+
+	country, err := db.Country(net.ParseIP("81.2.69.255"))
+	require.NoError(t, err)
+	assert.Equal(t, "GB", country)
+
+	country, err = db.Country(net.ParseIP("81.2.70.0"))
+	require.NoError(t, err)
+	assert.Empty(t, country)
+
+	country, err = db.Country(net.ParseIP("2001:218:ffff::1"))
+	require.NoError(t, err)
+	assert.Equal(t, "JP", country)
+}
+
+func TestCSV_Invalid(t *testing.T) {
+	_, err := NewCSV(strings.NewReader("1.0.0.0,1.0.0.255,AU\n5.0.0.0,broken,DE\n"))
+	assert.Error(t, err)
+}
+
+func TestLocator_Country(t *testing.T) {
+	db, err := NewCSV(strings.NewReader(testCSV))
+	require.NoError(t, err)
+	trusted, err := clientip.ParseNetworks([]string{"10.0.0.0/8"})
+	require.NoError(t, err)
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.RemoteAddr = "10.0.0.1:443"
+	r.Header.Set("X-Forwarded-For", "5.1.1.1")
+
+	assert.Equal(t, "DE", NewLocator(db, trusted).Country(r))
+	assert.Equal(t, "", NewLocator(db, nil).Country(r))
+	assert.Equal(t, "", NewLocator(nil, trusted).Country(r))
+}
@@ -0,0 +1,61 @@
+package geoip
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"url-shortener/internal/lib/clientip"
+)
+
+// DB resolves IP addresses to countries
+type DB interface {
+	Country(ip net.IP) (string, error)
+}
+
+// Open loads IP database from disk, the format is chosen by file extension: .mmdb or .csv
+func Open(path string) (DB, error) {
+	const op = "lib.geoip.Open"
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".mmdb":
+		return OpenMMDB(path)
+	case ".csv":
+		return OpenCSV(path)
+	default:
+		return nil, fmt.Errorf("%s: unsupported database format %q", op, path)
+	}
+}
+
+// Locator resolves the country of the request visitor
+type Locator struct {
+	db      DB
+	trusted []*net.IPNet
+}
+
+// NewLocator creates locator over db, requests from trusted proxies
+// are resolved by X-Forwarded-For. Nil db makes every visitor location unknown
+func NewLocator(db DB, trusted []*net.IPNet) *Locator {
+	return &Locator{db: db, trusted: trusted}
+}
+
+// Country returns ISO 3166-1 alpha-2 country code of the visitor or empty string if it is unknown
+func (l *Locator) Country(r *http.Request) string {
+	if l == nil || l.db == nil {
+		return ""
+	}
+
+	ip := clientip.FromRequest(r, l.trusted)
+	if ip == nil {
+		return ""
+	}
+
+	country, err := l.db.Country(ip)
+	if err != nil {
+		return ""
+	}
+
+	return country
+}
@@ -0,0 +1,86 @@
+package geoip
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+var (
+	ErrInvalidDatabase = errors.New("invalid database")
+)
+
+// MMDB is a MaxMind DB file loaded into memory, e.g. GeoLite2-Country or DB-IP country lite.
+// The reader limits the depth of data structures, so corrupt files with pointer cycles fail the lookup
+type MMDB struct {
+	reader *maxminddb.Reader
+}
+
+// mmdbRecord is the part of country records used by the service
+type mmdbRecord struct {
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+	RegisteredCountry struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"registered_country"`
+}
+
+// OpenMMDB reads MaxMind DB file from disk
+func OpenMMDB(path string) (*MMDB, error) {
+	const op = "lib.geoip.OpenMMDB"
+
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	db, err := NewMMDB(buf)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return db, nil
+}
+
+// NewMMDB parses MaxMind DB from its content
+func NewMMDB(buf []byte) (*MMDB, error) {
+	const op = "lib.geoip.NewMMDB"
+
+	reader, err := maxminddb.FromBytes(buf)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w: %v", op, ErrInvalidDatabase, err)
+	}
+	if reader.Metadata.IPVersion != 4 && reader.Metadata.IPVersion != 6 {
+		return nil, fmt.Errorf("%s: %w: unsupported ip version %d", op, ErrInvalidDatabase, reader.Metadata.IPVersion)
+	}
+
+	return &MMDB{reader: reader}, nil
+}
+
+// Country returns ISO 3166-1 alpha-2 country code of the address or empty string if it is unknown
+func (db *MMDB) Country(ip net.IP) (string, error) {
+	const op = "lib.geoip.MMDB.Country"
+
+	if ip.To16() == nil {
+		return "", nil
+	}
+	// IPv6 addresses are unknown to IPv4 databases
+	if ip.To4() == nil && db.reader.Metadata.IPVersion == 4 {
+		return "", nil
+	}
+
+	var record mmdbRecord
+	if err := db.reader.Lookup(ip, &record); err != nil {
+		return "", fmt.Errorf("%s: %w: %v", op, ErrInvalidDatabase, err)
+	}
+
+	if record.Country.ISOCode != "" {
+		return record.Country.ISOCode, nil
+	}
+
+	return record.RegisteredCountry.ISOCode, nil
+}
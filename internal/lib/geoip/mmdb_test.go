@@ -0,0 +1,229 @@
+package geoip
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// MaxMind DB format: https://maxmind.github.io/MaxMind-DB/
+var metadataMarker = []byte("\xAB\xCD\xEFMaxMind.com")
+
+// dataSectionSeparator is the number of zero bytes between the search tree and the data section
+const dataSectionSeparator = 16
+
+type testNetwork struct {
+	cidr    string
+	country string
+}
+
+// buildMMDB writes minimal MaxMind DB with a country record per network
+func buildMMDB(t *testing.T, ipVersion int, recordSize int, networks []testNetwork) []byte {
+	t.Helper()
+
+	const (
+		empty = iota
+		child
+		leaf
+	)
+	type record struct {
+		kind  int
+		value int
+	}
+
+	nodes := [][2]record{{}}
+	var data bytes.Buffer
+	offsets := map[string]int{}
+	keyOffset := -1
+
+	for _, network := range networks {
+		ip, ipNet, err := net.ParseCIDR(network.cidr)
+		require.NoError(t, err)
+		ones, _ := ipNet.Mask.Size()
+
+		key := ip.Mask(ipNet.Mask).To16()
+		if ip.To4() != nil {
+			if ipVersion == 4 {
+				key = ip.Mask(ipNet.Mask).To4()
+			} else {
+				key = append(make([]byte, 12), ip.Mask(ipNet.Mask).To4()...)
+				ones += 96
+			}
+		}
+
+		if _, ok := offsets[network.country]; !ok {
+			offsets[network.country] = data.Len()
+			data.WriteByte(7<<5 | 1)
+			if keyOffset < 0 {
+				keyOffset = data.Len()
+				writeString(&data, "country")
+			} else {
+				data.Write([]byte{1<<5 | byte(keyOffset>>8), byte(keyOffset)})
+			}
+			data.WriteByte(7<<5 | 1)
+			writeString(&data, "iso_code")
+			writeString(&data, network.country)
+		}
+
+		node := 0
+		for i := 0; i < ones; i++ {
+			bit := (key[i/8] >> (7 - i%8)) & 1
+			if i == ones-1 {
+				nodes[node][bit] = record{kind: leaf, value: offsets[network.country]}
+				break
+			}
+			if nodes[node][bit].kind != child {
+				nodes = append(nodes, [2]record{})
+				nodes[node][bit] = record{kind: child, value: len(nodes) - 1}
+			}
+			node = nodes[node][bit].value
+		}
+	}
+
+	nodeCount := len(nodes)
+	value := func(r record) uint32 {
+		switch r.kind {
+		case child:
+			return uint32(r.value)
+		case leaf:
+			return uint32(nodeCount + dataSectionSeparator + r.value)
+		default:
+			return uint32(nodeCount)
+		}
+	}
+
+	var buf bytes.Buffer
+	for _, node := range nodes {
+		left, right := value(node[0]), value(node[1])
+		switch recordSize {
+		case 24:
+			buf.Write([]byte{byte(left >> 16), byte(left >> 8), byte(left), byte(right >> 16), byte(right >> 8), byte(right)})
+		case 28:
+			buf.Write([]byte{
+				byte(left >> 16), byte(left >> 8), byte(left),
+				byte(left>>24)<<4 | byte(right>>24)&0x0F,
+				byte(right >> 16), byte(right >> 8), byte(right),
+			})
+		case 32:
+			_ = binary.Write(&buf, binary.BigEndian, [2]uint32{left, right})
+		}
+	}
+	buf.Write(make([]byte, dataSectionSeparator))
+	buf.Write(data.Bytes())
+	writeMetadata(&buf, nodeCount, recordSize, ipVersion)
+
+	return buf.Bytes()
+}
+
+func writeMetadata(buf *bytes.Buffer, nodeCount, recordSize, ipVersion int) {
+	buf.Write(metadataMarker)
+	buf.WriteByte(7<<5 | 4)
+	writeString(buf, "node_count")
+	buf.Write([]byte{6<<5 | 4})
+	_ = binary.Write(buf, binary.BigEndian, uint32(nodeCount))
+	writeString(buf, "record_size")
+	buf.Write([]byte{5<<5 | 2, byte(recordSize >> 8), byte(recordSize)})
+	writeString(buf, "ip_version")
+	buf.Write([]byte{5<<5 | 1, byte(ipVersion)})
+	writeString(buf, "database_type")
+	writeString(buf, "Test-Country")
+}
+
+func writeString(buf *bytes.Buffer, s string) {
+	buf.WriteByte(2<<5 | byte(len(s)))
+	buf.WriteString(s)
+}
+
+func TestMMDB_Country(t *testing.T) {
+	networks := []testNetwork{
+		{cidr: "81.2.69.0/24", country: "GB"},
+		{cidr: "89.160.20.112/28", country: "SE"},
+		{cidr: "2001:218::/32", country: "JP"},
+	}
+
+	tests := []struct {
+		ip   string
+		want string
+	}{
+		{ip: "81.2.69.142", want: "GB"},
+		{ip: "89.160.20.120", want: "SE"},
+		{ip: "89.160.20.140", want: ""},
+		{ip: "8.8.8.8", want: ""},
+		{ip: "2001:218:1::1", want: "JP"},
+		{ip: "2001:db8::1", want: ""},
+	}
+
+	for _, recordSize := range []int{24, 28, 32} {
+		db, err := NewMMDB(buildMMDB(t, 6, recordSize, networks))
+		require.NoError(t, err)
+
+		for _, tt := range tests {
+			country, err := db.Country(net.ParseIP(tt.ip))
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, country, "record size %d, ip %s", recordSize, tt.ip)
+		}
+	}
+}
+
+func TestMMDB_IPv4Database(t *testing.T) {
+	db, err := NewMMDB(buildMMDB(t, 4, 24, []testNetwork{{cidr: "81.2.69.0/24", country: "GB"}}))
+	require.NoError(t, err)
+
+	country, err := db.Country(net.ParseIP("81.2.69.1"))
+	require.NoError(t, err)
+	assert.Equal(t, "GB", country)
+
+	country, err = db.Country(net.ParseIP("2001:218::1"))
+	require.NoError(t, err)
+	assert.Empty(t, country)
+}
+
+func TestMMDB_Invalid(t *testing.T) {
+	_, err := NewMMDB([]byte("definitely not a database"))
+	assert.ErrorIs(t, err, ErrInvalidDatabase)
+}
+
+func TestMMDB_PointerCycle(t *testing.T) {
+	// a single node whose records point to a data pointer referring to itself
+	const nodeCount = 1
+	leaf := byte(nodeCount + dataSectionSeparator)
+
+	var buf bytes.Buffer
+	buf.Write([]byte{0, 0, leaf, 0, 0, leaf})
+	buf.Write(make([]byte, dataSectionSeparator))
+	buf.Write([]byte{1 << 5, 0})
+	writeMetadata(&buf, nodeCount, 24, 4)
+
+	db, err := NewMMDB(buf.Bytes())
+	require.NoError(t, err)
+
+	_, err = db.Country(net.ParseIP("81.2.69.1"))
+	assert.ErrorIs(t, err, ErrInvalidDatabase)
+}
+
+func TestOpen(t *testing.T) {
+	dir := t.TempDir()
+
+	mmdbPath := filepath.Join(dir, "country.mmdb")
+	require.NoError(t, os.WriteFile(mmdbPath, buildMMDB(t, 6, 28, []testNetwork{{cidr: "81.2.69.0/24", country: "GB"}}), 0o600))
+	csvPath := filepath.Join(dir, "country.csv")
+	require.NoError(t, os.WriteFile(csvPath, []byte("network,country\n81.2.69.0/24,GB\n"), 0o600))
+
+	for _, path := range []string{mmdbPath, csvPath} {
+		db, err := Open(path)
+		require.NoError(t, err)
+
+		country, err := db.Country(net.ParseIP("81.2.69.5"))
+		require.NoError(t, err)
+		assert.Equal(t, "GB", country)
+	}
+
+	_, err := Open(filepath.Join(dir, "country.dat"))
+	assert.Error(t, err)
+}
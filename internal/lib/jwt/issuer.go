@@ -0,0 +1,78 @@
+package jwt
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"url-shortener/internal/config"
+)
+
+// MinSecretSize is the minimal length of the HS256 secret of issued tokens
+const MinSecretSize = 32
+
+var (
+	ErrWeakSecret = errors.New("secret of issued tokens is too short")
+	ErrInvalidTTL = errors.New("token ttl must be positive")
+)
+
+// Issuer signs HS256 tokens in the claim format of SSO, so Verifier parses them with the same secret
+type Issuer struct {
+	secret   []byte
+	issuer   string
+	audience string
+	ttl      time.Duration
+}
+
+// NewIssuer creates the issuer of tokens valid for ttl, iss and aud are taken from the config
+// so the tokens pass the checks of the verifier
+func NewIssuer(secret []byte, cfg config.JWT, ttl time.Duration) (*Issuer, error) {
+	const op = "lib.jwt.NewIssuer"
+
+	if len(secret) < MinSecretSize {
+		return nil, fmt.Errorf("%s: %w: at least %d bytes are required", op, ErrWeakSecret, MinSecretSize)
+	}
+	if ttl <= 0 {
+		return nil, fmt.Errorf("%s: %w", op, ErrInvalidTTL)
+	}
+
+	return &Issuer{
+		secret:   secret,
+		issuer:   cfg.Issuer,
+		audience: cfg.Audience,
+		ttl:      ttl,
+	}, nil
+}
+
+// Issue signs a token of the user and returns it with its expiration time
+func (i *Issuer) Issue(uid int64, email string, level int8, scopes []string) (string, time.Time, error) {
+	const op = "lib.jwt.Issuer.Issue"
+
+	now := time.Now()
+	expiresAt := now.Add(i.ttl)
+
+	claims := Claims{
+		UID:   uid,
+		Email: email,
+		Level: level,
+		Scope: strings.Join(scopes, " "),
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    i.issuer,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}
+	if i.audience != "" {
+		claims.Audience = jwt.ClaimStrings{i.audience}
+	}
+
+	raw, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(i.secret)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return raw, expiresAt, nil
+}
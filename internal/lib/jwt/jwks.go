@@ -0,0 +1,269 @@
+package jwt
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"url-shortener/internal/config"
+	"url-shortener/internal/lib/logger/sl"
+)
+
+var (
+	ErrNoKeySource      = errors.New("jwks url or path is required")
+	ErrKeyNotFound      = errors.New("key not found")
+	ErrEmptyKeySet      = errors.New("no usable keys in jwks")
+	ErrUnsupportedKey   = errors.New("unsupported key")
+	ErrUnexpectedStatus = errors.New("unexpected status code")
+)
+
+// jwk is a public key of a JWKS document, only RSA and Ed25519 signing keys are used
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+}
+
+// KeySet caches the keys of a JWKS document by kid. The document is refreshed periodically
+// and on an unknown kid, the last loaded keys are kept while the source is unavailable
+type KeySet struct {
+	log         *slog.Logger
+	fetch       func(ctx context.Context) ([]byte, error)
+	interval    time.Duration
+	minInterval time.Duration
+	timeout     time.Duration
+
+	mu   sync.RWMutex
+	keys map[string]any
+
+	refreshMu   sync.Mutex
+	attemptedAt time.Time
+}
+
+// NewKeySet loads the document from the URL or the file of the config. A failed initial load is only logged
+// so the service starts with the other keys and picks the document up on the next refresh
+func NewKeySet(log *slog.Logger, cfg config.JWKS) (*KeySet, error) {
+	const op = "lib.jwt.NewKeySet"
+
+	ks := &KeySet{
+		log:         log.With(slog.String("component", "jwks")),
+		interval:    cfg.RefreshInterval,
+		minInterval: cfg.MinRefreshInterval,
+		timeout:     cfg.Timeout,
+		keys:        map[string]any{},
+	}
+
+	switch {
+	case cfg.URL != "":
+		client := &http.Client{Timeout: cfg.Timeout}
+		ks.fetch = func(ctx context.Context) ([]byte, error) {
+			return fetchURL(ctx, client, cfg.URL)
+		}
+	case cfg.Path != "":
+		ks.fetch = func(_ context.Context) ([]byte, error) {
+			return os.ReadFile(cfg.Path)
+		}
+	default:
+		return nil, fmt.Errorf("%s: %w", op, ErrNoKeySource)
+	}
+
+	if err := ks.Refresh(context.Background()); err != nil {
+		ks.log.Warn("failed to load jwks", sl.Err(err))
+	}
+
+	return ks, nil
+}
+
+// Run refreshes the keys every refresh interval until the context is done
+func (ks *KeySet) Run(ctx context.Context) {
+	if ks.interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(ks.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := ks.Refresh(ctx); err != nil {
+				ks.log.Warn("failed to refresh jwks, using cached keys", sl.Err(err))
+			}
+		}
+	}
+}
+
+// Refresh loads the document and replaces the cached keys, they are kept if the document can't be used
+func (ks *KeySet) Refresh(ctx context.Context) error {
+	ks.refreshMu.Lock()
+	defer ks.refreshMu.Unlock()
+
+	return ks.refresh(ctx)
+}
+
+// refresh must be called with refreshMu held
+func (ks *KeySet) refresh(ctx context.Context) error {
+	const op = "lib.jwt.KeySet.Refresh"
+
+	ks.attemptedAt = time.Now()
+
+	raw, err := ks.fetch(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	keys, err := ks.parse(raw)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	ks.mu.Lock()
+	ks.keys = keys
+	ks.mu.Unlock()
+
+	ks.log.Info("jwks loaded", slog.Int("keys", len(keys)))
+
+	return nil
+}
+
+// Key returns the key with the kid, an unknown kid refreshes the document at most once per min refresh interval.
+// The refresh is bounded by the timeout of the config and ctx of the request
+func (ks *KeySet) Key(ctx context.Context, kid string) (any, error) {
+	const op = "lib.jwt.KeySet.Key"
+
+	if key, ok := ks.cached(kid); ok {
+		return key, nil
+	}
+
+	attempted, err := ks.refreshStale(ctx)
+	if err != nil {
+		ks.log.Warn("failed to refresh jwks on unknown kid", slog.String("kid", kid), sl.Err(err))
+	}
+	if attempted {
+		if key, ok := ks.cached(kid); ok {
+			return key, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%s: %w: %s", op, ErrKeyNotFound, kid)
+}
+
+// refreshStale refreshes the document unless it was attempted within the min refresh interval.
+// The interval is checked under refreshMu, so concurrent unknown kids wait for a single fetch
+func (ks *KeySet) refreshStale(ctx context.Context) (bool, error) {
+	ks.refreshMu.Lock()
+	defer ks.refreshMu.Unlock()
+
+	if time.Since(ks.attemptedAt) < ks.minInterval {
+		return false, nil
+	}
+
+	if ks.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, ks.timeout)
+		defer cancel()
+	}
+
+	return true, ks.refresh(ctx)
+}
+
+func (ks *KeySet) cached(kid string) (any, bool) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	key, ok := ks.keys[kid]
+	return key, ok
+}
+
+func (ks *KeySet) parse(raw []byte) (map[string]any, error) {
+	var doc struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]any, len(doc.Keys))
+	for _, k := range doc.Keys {
+		if k.Kid == "" || (k.Use != "" && k.Use != "sig") {
+			continue
+		}
+		key, err := k.publicKey()
+		if err != nil {
+			ks.log.Warn("skipping jwk", slog.String("kid", k.Kid), sl.Err(err))
+			continue
+		}
+		keys[k.Kid] = key
+	}
+	if len(keys) == 0 {
+		return nil, ErrEmptyKeySet
+	}
+
+	return keys, nil
+}
+
+func (k jwk) publicKey() (any, error) {
+	switch {
+	case k.Kty == "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, err
+		}
+		exp := new(big.Int).SetBytes(e)
+		if len(n) == 0 || !exp.IsInt64() || exp.Int64() < 3 || exp.Int64() > 1<<31-1 {
+			return nil, fmt.Errorf("%w: invalid rsa parameters", ErrUnsupportedKey)
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exp.Int64())}, nil
+	case k.Kty == "OKP" && k.Crv == "Ed25519":
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("%w: invalid ed25519 key size", ErrUnsupportedKey)
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, fmt.Errorf("%w: kty %s", ErrUnsupportedKey, k.Kty)
+	}
+}
+
+func fetchURL(ctx context.Context, client *http.Client, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: %d", ErrUnexpectedStatus, resp.StatusCode)
+	}
+
+	return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+}
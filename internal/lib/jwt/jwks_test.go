@@ -0,0 +1,292 @@
+package jwt
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/config"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+)
+
+func rsaJWK(kid string, key *rsa.PublicKey) map[string]string {
+	return map[string]string{
+		"kty": "RSA",
+		"kid": kid,
+		"use": "sig",
+		"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
+
+func edJWK(kid string, key ed25519.PublicKey) map[string]string {
+	return map[string]string{
+		"kty": "OKP",
+		"crv": "Ed25519",
+		"kid": kid,
+		"x":   base64.RawURLEncoding.EncodeToString(key),
+	}
+}
+
+func document(t *testing.T, keys ...map[string]string) []byte {
+	t.Helper()
+
+	raw, err := json.Marshal(map[string]any{"keys": keys})
+	require.NoError(t, err)
+
+	return raw
+}
+
+func signKid(t *testing.T, method jwt.SigningMethod, key any, kid string) string {
+	t.Helper()
+
+	token := jwt.NewWithClaims(method, claims(nil))
+	token.Header["kid"] = kid
+	raw, err := token.SignedString(key)
+	require.NoError(t, err)
+
+	return raw
+}
+
+// jwksServer serves the current document or fails with the current status
+type jwksServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	doc      []byte
+	status   int
+	requests atomic.Int32
+}
+
+func newJWKSServer(t *testing.T, doc []byte) *jwksServer {
+	s := &jwksServer{doc: doc, status: http.StatusOK}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.requests.Add(1)
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		w.WriteHeader(s.status)
+		_, _ = w.Write(s.doc)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *jwksServer) set(doc []byte, status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.doc, s.status = doc, status
+}
+
+func TestKeySet_Verify(t *testing.T) {
+	k := newKeys(t)
+	rotated := newKeys(t)
+	edPub := rotated.ed.Public().(ed25519.PublicKey)
+
+	srv := newJWKSServer(t, document(t, rsaJWK("a", &k.rsa.PublicKey)))
+	ks, err := NewKeySet(slogdiscard.NewDiscardLogger(), config.JWKS{URL: srv.URL, Timeout: time.Second})
+	require.NoError(t, err)
+
+	v := NewVerifier(Options{Secret: []byte(secret), KeySet: ks})
+
+	// the document is not reloaded yet, so the rotated key is unknown
+	srv.set(document(t, rsaJWK("a", &k.rsa.PublicKey), edJWK("b", edPub)), http.StatusOK)
+
+	tests := []struct {
+		name    string
+		raw     string
+		wantErr error
+	}{
+		{name: "rs256 by kid", raw: signKid(t, jwt.SigningMethodRS256, k.rsa, "a")},
+		{name: "rotated key is fetched", raw: signKid(t, jwt.SigningMethodEdDSA, rotated.ed, "b")},
+		{name: "hmac without kid", raw: sign(t, jwt.SigningMethodHS256, []byte(secret), claims(nil))},
+		{name: "unknown kid", raw: signKid(t, jwt.SigningMethodRS256, rotated.rsa, "c"), wantErr: ErrKeyNotFound},
+		{name: "wrong key for kid", raw: signKid(t, jwt.SigningMethodRS256, rotated.rsa, "a"), wantErr: jwt.ErrTokenSignatureInvalid},
+		{name: "method does not match key", raw: signKid(t, jwt.SigningMethodHS256, []byte(secret), "a"), wantErr: ErrUnexpectedKey},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := v.Parse(context.Background(), tt.raw)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestKeySet_Fallback(t *testing.T) {
+	k := newKeys(t)
+	srv := newJWKSServer(t, document(t, rsaJWK("a", &k.rsa.PublicKey)))
+
+	ks, err := NewKeySet(slogdiscard.NewDiscardLogger(), config.JWKS{URL: srv.URL, Timeout: time.Second})
+	require.NoError(t, err)
+
+	srv.set([]byte("unavailable"), http.StatusInternalServerError)
+	assert.ErrorIs(t, ks.Refresh(context.Background()), ErrUnexpectedStatus)
+
+	srv.set([]byte(`{"keys": [{"kty": "EC", "kid": "x"}]}`), http.StatusOK)
+	assert.ErrorIs(t, ks.Refresh(context.Background()), ErrEmptyKeySet)
+
+	// the cached keys are still used
+	key, err := ks.Key(context.Background(), "a")
+	require.NoError(t, err)
+	assert.Equal(t, &k.rsa.PublicKey, key)
+}
+
+func TestKeySet_UnavailableOnStart(t *testing.T) {
+	k := newKeys(t)
+	srv := newJWKSServer(t, nil)
+	srv.set(nil, http.StatusServiceUnavailable)
+
+	ks, err := NewKeySet(slogdiscard.NewDiscardLogger(), config.JWKS{URL: srv.URL, Timeout: time.Second})
+	require.NoError(t, err)
+
+	v := NewVerifier(Options{Secret: []byte(secret), KeySet: ks})
+
+	_, err = v.Parse(context.Background(), sign(t, jwt.SigningMethodHS256, []byte(secret), claims(nil)))
+	assert.NoError(t, err)
+
+	srv.set(document(t, rsaJWK("a", &k.rsa.PublicKey)), http.StatusOK)
+	_, err = v.Parse(context.Background(), signKid(t, jwt.SigningMethodRS256, k.rsa, "a"))
+	assert.NoError(t, err)
+}
+
+func TestKeySet_MinRefreshInterval(t *testing.T) {
+	k := newKeys(t)
+	srv := newJWKSServer(t, document(t, rsaJWK("a", &k.rsa.PublicKey)))
+
+	ks, err := NewKeySet(slogdiscard.NewDiscardLogger(), config.JWKS{
+		URL:                srv.URL,
+		Timeout:            time.Second,
+		MinRefreshInterval: time.Hour,
+	})
+	require.NoError(t, err)
+	require.EqualValues(t, 1, srv.requests.Load())
+
+	for i := 0; i < 3; i++ {
+		_, err = ks.Key(context.Background(), "unknown")
+		assert.ErrorIs(t, err, ErrKeyNotFound)
+	}
+	assert.EqualValues(t, 1, srv.requests.Load())
+}
+
+func TestKeySet_ConcurrentUnknownKids(t *testing.T) {
+	k := newKeys(t)
+	srv := newJWKSServer(t, document(t, rsaJWK("a", &k.rsa.PublicKey)))
+
+	ks, err := NewKeySet(slogdiscard.NewDiscardLogger(), config.JWKS{
+		URL:                srv.URL,
+		Timeout:            time.Second,
+		MinRefreshInterval: 200 * time.Millisecond,
+	})
+	require.NoError(t, err)
+	require.EqualValues(t, 1, srv.requests.Load())
+	time.Sleep(250 * time.Millisecond)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, err := ks.Key(context.Background(), fmt.Sprintf("unknown-%d", i))
+			assert.ErrorIs(t, err, ErrKeyNotFound)
+		}(i)
+	}
+	wg.Wait()
+
+	// the interval is checked under the lock, so the requests waiting for the refresh don't fetch again
+	assert.EqualValues(t, 2, srv.requests.Load())
+}
+
+func TestKeySet_KeyContext(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(srv.Close)
+	t.Cleanup(func() { close(release) })
+
+	ks := &KeySet{
+		log:     slogdiscard.NewDiscardLogger(),
+		timeout: time.Minute,
+		keys:    map[string]any{},
+		fetch: func(ctx context.Context) ([]byte, error) {
+			return fetchURL(ctx, srv.Client(), srv.URL)
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := ks.Key(ctx, "unknown")
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+	assert.Less(t, time.Since(start), 5*time.Second, "the refresh must stop with the request context")
+}
+
+func TestKeySet_Run(t *testing.T) {
+	k := newKeys(t)
+	srv := newJWKSServer(t, document(t, rsaJWK("a", &k.rsa.PublicKey)))
+
+	ks, err := NewKeySet(slogdiscard.NewDiscardLogger(), config.JWKS{
+		URL:                srv.URL,
+		Timeout:            time.Second,
+		RefreshInterval:    10 * time.Millisecond,
+		MinRefreshInterval: time.Hour,
+	})
+	require.NoError(t, err)
+
+	srv.set(document(t, rsaJWK("b", &k.rsa.PublicKey)), http.StatusOK)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go ks.Run(ctx)
+
+	assert.Eventually(t, func() bool {
+		_, ok := ks.cached("b")
+		return ok
+	}, time.Second, 10*time.Millisecond)
+	_, ok := ks.cached("a")
+	assert.False(t, ok)
+}
+
+func TestKeySet_File(t *testing.T) {
+	k := newKeys(t)
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(path, document(t, edJWK("ed", k.ed.Public().(ed25519.PublicKey))), 0o600))
+
+	ks, err := NewKeySet(slogdiscard.NewDiscardLogger(), config.JWKS{Path: path})
+	require.NoError(t, err)
+
+	v, err := New("", ks, config.JWT{})
+	require.NoError(t, err)
+
+	_, err = v.Parse(context.Background(), signKid(t, jwt.SigningMethodEdDSA, k.ed, "ed"))
+	assert.NoError(t, err)
+}
+
+func TestNewKeySet_NoSource(t *testing.T) {
+	_, err := NewKeySet(slogdiscard.NewDiscardLogger(), config.JWKS{})
+	assert.ErrorIs(t, err, ErrNoKeySource)
+}
@@ -1,10 +1,24 @@
 package jwt
 
 import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"errors"
 	"fmt"
+	"os"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+
+	"url-shortener/internal/config"
+)
+
+var (
+	ErrUnexpectedKey = errors.New("no key for signing method")
+	ErrInvalidClaims = errors.New("invalid claims")
 )
 
 type Token struct {
@@ -12,25 +26,213 @@ type Token struct {
 	Email      string
 	Expiration time.Time
 	Level      int8
+	// Scopes granted by the issuer, empty if the token has no scope claim
+	Scopes []string
+}
+
+// Claims are the claims of the tokens issued by SSO
+type Claims struct {
+	UID   int64  `json:"uid"`
+	Email string `json:"email"`
+	Level int8   `json:"level"`
+	// Scope is the space separated list of scopes, ScopeList is the array form of it
+	Scope     string   `json:"scope,omitempty"`
+	ScopeList []string `json:"scopes,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// Verifier parses tokens signed with one of its keys, the signing method of a token must match the key type
+type Verifier struct {
+	mu     sync.RWMutex
+	keys   map[string]any
+	keySet *KeySet
+	parser *jwt.Parser
+}
+
+// Options are the keys and claim requirements of the verifier, a zero option disables the key or the check
+type Options struct {
+	// Secret verifies HS256 tokens, it may be set later by SetSecret
+	Secret []byte
+	// RSAKey verifies RS256 tokens
+	RSAKey *rsa.PublicKey
+	// EdKey verifies EdDSA tokens
+	EdKey ed25519.PublicKey
+	// KeySet verifies RS256 and EdDSA tokens having the kid header
+	KeySet   *KeySet
+	Issuer   string
+	Audience string
+	// Leeway is the allowed clock skew for exp, nbf and iat
+	Leeway time.Duration
+}
+
+// NewVerifier creates the verifier accepting only the signing methods it has keys for.
+// HS256 tokens are accepted once the secret is set
+func NewVerifier(opts Options) *Verifier {
+	keys := make(map[string]any)
+	if len(opts.Secret) > 0 {
+		keys[jwt.SigningMethodHS256.Alg()] = opts.Secret
+	}
+	if opts.RSAKey != nil {
+		keys[jwt.SigningMethodRS256.Alg()] = opts.RSAKey
+	}
+	if opts.EdKey != nil {
+		keys[jwt.SigningMethodEdDSA.Alg()] = opts.EdKey
+	}
+
+	methods := []string{jwt.SigningMethodHS256.Alg()}
+	if opts.RSAKey != nil || opts.KeySet != nil {
+		methods = append(methods, jwt.SigningMethodRS256.Alg())
+	}
+	if opts.EdKey != nil || opts.KeySet != nil {
+		methods = append(methods, jwt.SigningMethodEdDSA.Alg())
+	}
+
+	parserOpts := []jwt.ParserOption{
+		jwt.WithValidMethods(methods),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(opts.Leeway),
+	}
+	if opts.Issuer != "" {
+		parserOpts = append(parserOpts, jwt.WithIssuer(opts.Issuer))
+	}
+	if opts.Audience != "" {
+		parserOpts = append(parserOpts, jwt.WithAudience(opts.Audience))
+	}
+
+	return &Verifier{
+		keys:   keys,
+		keySet: opts.KeySet,
+		parser: jwt.NewParser(parserOpts...),
+	}
+}
+
+// New creates the verifier from the config, secret is the HMAC key issued by SSO on app registration,
+// keySet may be nil if JWKS is not configured
+func New(secret string, keySet *KeySet, cfg config.JWT) (*Verifier, error) {
+	const op = "lib.jwt.New"
+
+	opts := Options{
+		Secret:   []byte(secret),
+		KeySet:   keySet,
+		Issuer:   cfg.Issuer,
+		Audience: cfg.Audience,
+		Leeway:   cfg.Leeway,
+	}
+
+	if cfg.RSAPublicKeyPath != "" {
+		pem, err := os.ReadFile(cfg.RSAPublicKeyPath)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		opts.RSAKey, err = jwt.ParseRSAPublicKeyFromPEM(pem)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if cfg.EdDSAPublicKeyPath != "" {
+		pem, err := os.ReadFile(cfg.EdDSAPublicKeyPath)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		key, err := jwt.ParseEdPublicKeyFromPEM(pem)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		edKey, ok := key.(ed25519.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("%s: %w", op, jwt.ErrNotEdPublicKey)
+		}
+		opts.EdKey = edKey
+	}
+
+	return NewVerifier(opts), nil
 }
 
-func Parse(raw, appSecret string) (*Token, error) {
+// SetSecret replaces the HS256 secret, an empty secret stops accepting HS256 tokens
+func (v *Verifier) SetSecret(secret []byte) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if len(secret) == 0 {
+		delete(v.keys, jwt.SigningMethodHS256.Alg())
+		return
+	}
+	v.keys[jwt.SigningMethodHS256.Alg()] = secret
+}
+
+// Parse verifies the signature and the registered claims of the token and returns its typed claims.
+// ctx bounds the JWKS refresh caused by an unknown kid
+func (v *Verifier) Parse(ctx context.Context, raw string) (*Token, error) {
 	const op = "lib.jwt.Parse"
 
-	claims := jwt.MapClaims{}
-	_, err := jwt.ParseWithClaims(raw, &claims, func(t *jwt.Token) (interface{}, error) {
-		return []byte(appSecret), nil
+	var claims Claims
+	_, err := v.parser.ParseWithClaims(raw, &claims, func(t *jwt.Token) (any, error) {
+		return v.key(ctx, t)
 	})
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
+	if claims.UID <= 0 || claims.Email == "" {
+		return nil, fmt.Errorf("%s: %w: uid and email are required", op, ErrInvalidClaims)
+	}
+
 	token := &Token{
-		UID:        int64(claims["uid"].(float64)),
-		Email:      claims["email"].(string),
-		Expiration: time.Unix(int64(claims["exp"].(float64)), 0),
-		Level:      int8(claims["level"].(float64)),
+		UID:        claims.UID,
+		Email:      claims.Email,
+		Expiration: claims.ExpiresAt.Time,
+		Level:      claims.Level,
+		Scopes:     claims.scopes(),
 	}
 
 	return token, nil
 }
+
+// key picks the JWKS key by kid or the static key by the signing method
+func (v *Verifier) key(ctx context.Context, t *jwt.Token) (any, error) {
+	alg := t.Method.Alg()
+
+	kid, _ := t.Header["kid"].(string)
+	if kid != "" && v.keySet != nil {
+		key, err := v.keySet.Key(ctx, kid)
+		if err != nil {
+			return nil, err
+		}
+		if !keyMatches(alg, key) {
+			return nil, fmt.Errorf("%w %s", ErrUnexpectedKey, alg)
+		}
+		return key, nil
+	}
+
+	v.mu.RLock()
+	key, ok := v.keys[alg]
+	v.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w %s", ErrUnexpectedKey, alg)
+	}
+
+	return key, nil
+}
+
+// keyMatches reports whether the JWKS key may verify the signing method, HMAC is never verified with them
+func keyMatches(alg string, key any) bool {
+	switch key.(type) {
+	case *rsa.PublicKey:
+		return alg == jwt.SigningMethodRS256.Alg()
+	case ed25519.PublicKey:
+		return alg == jwt.SigningMethodEdDSA.Alg()
+	default:
+		return false
+	}
+}
+
+// scopes reads the space separated "scope" claim or the "scopes" array claim
+func (c Claims) scopes() []string {
+	if c.Scope != "" {
+		return strings.Fields(c.Scope)
+	}
+
+	return c.ScopeList
+}
@@ -0,0 +1,310 @@
+package jwt
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/config"
+)
+
+const secret = "test-secret"
+
+type keys struct {
+	rsa *rsa.PrivateKey
+	ed  ed25519.PrivateKey
+}
+
+func newKeys(t *testing.T) keys {
+	t.Helper()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	return keys{rsa: rsaKey, ed: edKey}
+}
+
+func claims(override jwt.MapClaims) jwt.MapClaims {
+	c := jwt.MapClaims{
+		"uid":   1,
+		"email": "user@example.com",
+		"level": 1,
+		"iss":   "sso",
+		"aud":   "url-shortener",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"iat":   time.Now().Unix(),
+	}
+	for k, v := range override {
+		if v == nil {
+			delete(c, k)
+			continue
+		}
+		c[k] = v
+	}
+	return c
+}
+
+func sign(t *testing.T, method jwt.SigningMethod, key any, c jwt.MapClaims) string {
+	t.Helper()
+
+	raw, err := jwt.NewWithClaims(method, c).SignedString(key)
+	require.NoError(t, err)
+
+	return raw
+}
+
+func TestVerifier_Parse(t *testing.T) {
+	k := newKeys(t)
+	v := NewVerifier(Options{
+		Secret:   []byte(secret),
+		RSAKey:   &k.rsa.PublicKey,
+		EdKey:    k.ed.Public().(ed25519.PublicKey),
+		Issuer:   "sso",
+		Audience: "url-shortener",
+		Leeway:   30 * time.Second,
+	})
+	hmacOnly := NewVerifier(Options{Secret: []byte(secret)})
+
+	now := time.Now()
+	unsigned, err := jwt.NewWithClaims(jwt.SigningMethodNone, claims(nil)).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		verifier *Verifier
+		raw      string
+		want     *Token
+		wantErr  error
+	}{
+		{
+			name: "hs256",
+			raw:  sign(t, jwt.SigningMethodHS256, []byte(secret), claims(nil)),
+			want: &Token{UID: 1, Email: "user@example.com", Level: 1},
+		},
+		{
+			name: "rs256",
+			raw:  sign(t, jwt.SigningMethodRS256, k.rsa, claims(nil)),
+			want: &Token{UID: 1, Email: "user@example.com", Level: 1},
+		},
+		{
+			name: "eddsa",
+			raw:  sign(t, jwt.SigningMethodEdDSA, k.ed, claims(nil)),
+			want: &Token{UID: 1, Email: "user@example.com", Level: 1},
+		},
+		{
+			name: "scope claim",
+			raw:  sign(t, jwt.SigningMethodHS256, []byte(secret), claims(jwt.MapClaims{"scope": "links:read admin"})),
+			want: &Token{UID: 1, Email: "user@example.com", Level: 1, Scopes: []string{"links:read", "admin"}},
+		},
+		{
+			name: "scopes array claim",
+			raw:  sign(t, jwt.SigningMethodHS256, []byte(secret), claims(jwt.MapClaims{"scopes": []string{"links:write"}})),
+			want: &Token{UID: 1, Email: "user@example.com", Level: 1, Scopes: []string{"links:write"}},
+		},
+		{
+			name:    "wrong secret",
+			raw:     sign(t, jwt.SigningMethodHS256, []byte("other"), claims(nil)),
+			wantErr: jwt.ErrTokenSignatureInvalid,
+		},
+		{
+			name:    "alg none",
+			raw:     unsigned,
+			wantErr: jwt.ErrTokenSignatureInvalid,
+		},
+		{
+			name:    "hmac method not pinned",
+			raw:     sign(t, jwt.SigningMethodHS512, []byte(secret), claims(nil)),
+			wantErr: jwt.ErrTokenSignatureInvalid,
+		},
+		{
+			name:     "rs256 without key",
+			verifier: hmacOnly,
+			raw:      sign(t, jwt.SigningMethodRS256, k.rsa, claims(nil)),
+			wantErr:  jwt.ErrTokenSignatureInvalid,
+		},
+		{
+			name:    "wrong issuer",
+			raw:     sign(t, jwt.SigningMethodHS256, []byte(secret), claims(jwt.MapClaims{"iss": "evil"})),
+			wantErr: jwt.ErrTokenInvalidIssuer,
+		},
+		{
+			name:    "wrong audience",
+			raw:     sign(t, jwt.SigningMethodHS256, []byte(secret), claims(jwt.MapClaims{"aud": []string{"billing"}})),
+			wantErr: jwt.ErrTokenInvalidAudience,
+		},
+		{
+			name:    "no expiration",
+			raw:     sign(t, jwt.SigningMethodHS256, []byte(secret), claims(jwt.MapClaims{"exp": nil})),
+			wantErr: jwt.ErrTokenRequiredClaimMissing,
+		},
+		{
+			name:    "expired",
+			raw:     sign(t, jwt.SigningMethodHS256, []byte(secret), claims(jwt.MapClaims{"exp": now.Add(-time.Minute).Unix()})),
+			wantErr: jwt.ErrTokenExpired,
+		},
+		{
+			name: "expired within leeway",
+			raw:  sign(t, jwt.SigningMethodHS256, []byte(secret), claims(jwt.MapClaims{"exp": now.Add(-10 * time.Second).Unix()})),
+			want: &Token{UID: 1, Email: "user@example.com", Level: 1},
+		},
+		{
+			name:    "not valid yet",
+			raw:     sign(t, jwt.SigningMethodHS256, []byte(secret), claims(jwt.MapClaims{"nbf": now.Add(time.Minute).Unix()})),
+			wantErr: jwt.ErrTokenNotValidYet,
+		},
+		{
+			name: "not valid yet within leeway",
+			raw:  sign(t, jwt.SigningMethodHS256, []byte(secret), claims(jwt.MapClaims{"nbf": now.Add(10 * time.Second).Unix()})),
+			want: &Token{UID: 1, Email: "user@example.com", Level: 1},
+		},
+		{
+			name:    "issued in the future",
+			raw:     sign(t, jwt.SigningMethodHS256, []byte(secret), claims(jwt.MapClaims{"iat": now.Add(time.Hour).Unix()})),
+			wantErr: jwt.ErrTokenUsedBeforeIssued,
+		},
+		{
+			name:    "uid of wrong type",
+			raw:     sign(t, jwt.SigningMethodHS256, []byte(secret), claims(jwt.MapClaims{"uid": "1"})),
+			wantErr: jwt.ErrTokenMalformed,
+		},
+		{
+			name:    "scopes of wrong type",
+			raw:     sign(t, jwt.SigningMethodHS256, []byte(secret), claims(jwt.MapClaims{"scopes": "links:read"})),
+			wantErr: jwt.ErrTokenMalformed,
+		},
+		{
+			name:    "no uid",
+			raw:     sign(t, jwt.SigningMethodHS256, []byte(secret), claims(jwt.MapClaims{"uid": nil})),
+			wantErr: ErrInvalidClaims,
+		},
+		{
+			name:    "no email",
+			raw:     sign(t, jwt.SigningMethodHS256, []byte(secret), claims(jwt.MapClaims{"email": nil})),
+			wantErr: ErrInvalidClaims,
+		},
+		{
+			name:    "garbage",
+			raw:     "not.a.token",
+			wantErr: jwt.ErrTokenMalformed,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verifier := tt.verifier
+			if verifier == nil {
+				verifier = v
+			}
+
+			token, err := verifier.Parse(context.Background(), tt.raw)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.want.UID, token.UID)
+			assert.Equal(t, tt.want.Email, token.Email)
+			assert.Equal(t, tt.want.Level, token.Level)
+			assert.Equal(t, tt.want.Scopes, token.Scopes)
+			assert.False(t, token.Expiration.IsZero())
+		})
+	}
+}
+
+func TestVerifier_SetSecret(t *testing.T) {
+	v := NewVerifier(Options{})
+	raw := sign(t, jwt.SigningMethodHS256, []byte(secret), claims(nil))
+
+	// the secret is unknown until the app is registered
+	_, err := v.Parse(context.Background(), raw)
+	assert.ErrorIs(t, err, ErrUnexpectedKey)
+
+	v.SetSecret([]byte(secret))
+	_, err = v.Parse(context.Background(), raw)
+	assert.NoError(t, err)
+
+	v.SetSecret(nil)
+	_, err = v.Parse(context.Background(), raw)
+	assert.ErrorIs(t, err, ErrUnexpectedKey)
+}
+
+func TestNew(t *testing.T) {
+	k := newKeys(t)
+	dir := t.TempDir()
+
+	rsaDER, err := x509.MarshalPKIXPublicKey(&k.rsa.PublicKey)
+	require.NoError(t, err)
+	edDER, err := x509.MarshalPKIXPublicKey(k.ed.Public())
+	require.NoError(t, err)
+
+	rsaPath := filepath.Join(dir, "rsa.pem")
+	edPath := filepath.Join(dir, "ed.pem")
+	require.NoError(t, os.WriteFile(rsaPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: rsaDER}), 0o600))
+	require.NoError(t, os.WriteFile(edPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: edDER}), 0o600))
+
+	v, err := New("", nil, config.JWT{RSAPublicKeyPath: rsaPath, EdDSAPublicKeyPath: edPath})
+	require.NoError(t, err)
+
+	_, err = v.Parse(context.Background(), sign(t, jwt.SigningMethodRS256, k.rsa, claims(nil)))
+	assert.NoError(t, err)
+	_, err = v.Parse(context.Background(), sign(t, jwt.SigningMethodEdDSA, k.ed, claims(nil)))
+	assert.NoError(t, err)
+	// without the secret HMAC tokens are not accepted at all
+	_, err = v.Parse(context.Background(), sign(t, jwt.SigningMethodHS256, []byte(""), claims(nil)))
+	assert.ErrorIs(t, err, ErrUnexpectedKey)
+
+	_, err = New("", nil, config.JWT{RSAPublicKeyPath: edPath})
+	assert.Error(t, err)
+	_, err = New("", nil, config.JWT{EdDSAPublicKeyPath: filepath.Join(dir, "missing.pem")})
+	assert.Error(t, err)
+}
+
+func TestIssuer_Issue(t *testing.T) {
+	key := []byte(strings.Repeat("k", MinSecretSize))
+	cfg := config.JWT{Issuer: "url-shortener", Audience: "url-shortener"}
+
+	issuer, err := NewIssuer(key, cfg, time.Hour)
+	require.NoError(t, err)
+
+	raw, expiresAt, err := issuer.Issue(7, "local@example.com", 2, []string{"links:read", "admin"})
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), expiresAt, time.Minute)
+
+	v := NewVerifier(Options{Secret: key, Issuer: cfg.Issuer, Audience: cfg.Audience})
+	token, err := v.Parse(context.Background(), raw)
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), token.UID)
+	assert.Equal(t, "local@example.com", token.Email)
+	assert.Equal(t, int8(2), token.Level)
+	assert.Equal(t, []string{"links:read", "admin"}, token.Scopes)
+	assert.True(t, token.Expiration.Equal(expiresAt.Truncate(time.Second)))
+
+	// no scope claim is issued for users without scopes, they get the default scopes
+	raw, _, err = issuer.Issue(7, "local@example.com", 1, nil)
+	require.NoError(t, err)
+	token, err = v.Parse(context.Background(), raw)
+	require.NoError(t, err)
+	assert.Empty(t, token.Scopes)
+
+	_, err = NewVerifier(Options{Secret: []byte(secret)}).Parse(context.Background(), raw)
+	assert.ErrorIs(t, err, jwt.ErrTokenSignatureInvalid)
+
+	_, err = NewIssuer([]byte("short"), cfg, time.Hour)
+	assert.ErrorIs(t, err, ErrWeakSecret)
+	_, err = NewIssuer(key, cfg, 0)
+	assert.ErrorIs(t, err, ErrInvalidTTL)
+}
@@ -0,0 +1,183 @@
+package qrcode
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"strconv"
+	"strings"
+
+	goqrcode "github.com/skip2/go-qrcode"
+)
+
+// Error correction levels restoring about 7%, 15%, 25% and 30% of damaged code
+const (
+	LevelLow      = "L"
+	LevelMedium   = "M"
+	LevelQuartile = "Q"
+	LevelHigh     = "H"
+)
+
+var (
+	ErrInvalidLevel = errors.New("invalid error correction level")
+	ErrInvalidColor = errors.New("invalid color")
+)
+
+// Options controls the rendering of QR code
+type Options struct {
+	// Size is the image width and height in pixels
+	Size int
+	// Level is the error correction level, one of L M Q H
+	Level string
+	// Margin is the quiet zone width in modules
+	Margin     int
+	Foreground color.RGBA
+	Background color.RGBA
+}
+
+// Code is an encoded QR code with its quiet zone
+type Code struct {
+	modules [][]bool
+	opts    Options
+}
+
+// New encodes content to QR code
+func New(content string, opts Options) (*Code, error) {
+	const op = "lib.qrcode.New"
+
+	level, err := recoveryLevel(opts.Level)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	qr, err := goqrcode.New(content, level)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	qr.DisableBorder = true
+
+	return &Code{modules: withMargin(qr.Bitmap(), opts.Margin), opts: opts}, nil
+}
+
+// Modules returns the number of modules in a row including the quiet zone
+func (c *Code) Modules() int {
+	return len(c.modules)
+}
+
+// PNG renders the code as PNG image. The code is scaled by a whole number of pixels
+// per module and centered, so the image is never smaller than the number of modules
+func (c *Code) PNG() ([]byte, error) {
+	const op = "lib.qrcode.PNG"
+
+	n := len(c.modules)
+	size := c.opts.Size
+	if size < n {
+		size = n
+	}
+	scale := size / n
+	offset := (size - n*scale) / 2
+
+	img := image.NewPaletted(image.Rect(0, 0, size, size), color.Palette{c.opts.Background, c.opts.Foreground})
+	for y := 0; y < n*scale; y++ {
+		for x := 0; x < n*scale; x++ {
+			if c.modules[y/scale][x/scale] {
+				img.SetColorIndex(offset+x, offset+y, 1)
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := (&png.Encoder{CompressionLevel: png.BestCompression}).Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// SVG renders the code as SVG image with one path of dark modules
+func (c *Code) SVG() []byte {
+	n := len(c.modules)
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`,
+		c.opts.Size, c.opts.Size, n, n)
+	fmt.Fprintf(&buf, `<rect width="%d" height="%d" fill="%s"/>`, n, n, Hex(c.opts.Background))
+	fmt.Fprintf(&buf, `<path fill="%s" d="`, Hex(c.opts.Foreground))
+	for y, row := range c.modules {
+		for x := 0; x < n; x++ {
+			if !row[x] {
+				continue
+			}
+			// adjacent dark modules of the row are drawn as one rectangle
+			start := x
+			for x < n && row[x] {
+				x++
+			}
+			fmt.Fprintf(&buf, "M%d %dh%dv1h-%dz", start, y, x-start, x-start)
+		}
+	}
+	buf.WriteString(`"/></svg>`)
+
+	return buf.Bytes()
+}
+
+// ParseColor parses hex color in RRGGBB or RGB form with optional leading #
+func ParseColor(s string) (color.RGBA, error) {
+	const op = "lib.qrcode.ParseColor"
+
+	hex := strings.TrimPrefix(s, "#")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if len(hex) != 6 {
+		return color.RGBA{}, fmt.Errorf("%s: %w: %q", op, ErrInvalidColor, s)
+	}
+
+	value, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return color.RGBA{}, fmt.Errorf("%s: %w: %q", op, ErrInvalidColor, s)
+	}
+
+	return color.RGBA{R: uint8(value >> 16), G: uint8(value >> 8), B: uint8(value), A: 0xFF}, nil
+}
+
+// Hex formats color as #rrggbb
+func Hex(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+func recoveryLevel(level string) (goqrcode.RecoveryLevel, error) {
+	switch strings.ToUpper(level) {
+	case LevelLow:
+		return goqrcode.Low, nil
+	case LevelMedium, "":
+		return goqrcode.Medium, nil
+	case LevelQuartile:
+		return goqrcode.High, nil
+	case LevelHigh:
+		return goqrcode.Highest, nil
+	default:
+		return 0, fmt.Errorf("%w: %q", ErrInvalidLevel, level)
+	}
+}
+
+// withMargin surrounds the code modules with margin light modules on each side
+func withMargin(modules [][]bool, margin int) [][]bool {
+	if margin <= 0 {
+		return modules
+	}
+
+	n := len(modules) + 2*margin
+	res := make([][]bool, n)
+	for y := range res {
+		res[y] = make([]bool, n)
+		if y >= margin && y < n-margin {
+			copy(res[y][margin:], modules[y-margin])
+		}
+	}
+
+	return res
+}
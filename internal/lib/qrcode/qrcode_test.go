@@ -0,0 +1,136 @@
+package qrcode
+
+import (
+	"bytes"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	black = color.RGBA{A: 0xFF}
+	white = color.RGBA{R: 0xFF, G: 0xFF, B: 0xFF, A: 0xFF}
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name        string
+		opts        Options
+		wantModules int
+		wantErr     error
+	}{
+		{name: "default level", opts: Options{}, wantModules: 25},
+		{name: "low level fits smallest version", opts: Options{Level: LevelLow}, wantModules: 21},
+		{name: "margin", opts: Options{Level: LevelLow, Margin: 4}, wantModules: 29},
+		{name: "high level needs larger version", opts: Options{Level: LevelHigh}, wantModules: 29},
+		{name: "lowercase level", opts: Options{Level: "q"}, wantModules: 25},
+		{name: "unknown level", opts: Options{Level: "X"}, wantErr: ErrInvalidLevel},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 16 bytes fit version 1 with L, 2 with M and Q, 3 with H
+			code, err := New("https://ex.co/ab", tt.opts)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantModules, code.Modules())
+		})
+	}
+}
+
+func TestNew_Margin(t *testing.T) {
+	code, err := New("http://localhost:8085/abc123", Options{Margin: 2})
+	require.NoError(t, err)
+
+	n := code.Modules()
+	for i := 0; i < n; i++ {
+		for _, m := range []int{0, 1, n - 2, n - 1} {
+			assert.False(t, code.modules[m][i])
+			assert.False(t, code.modules[i][m])
+		}
+	}
+	// top left corner of the finder pattern
+	assert.True(t, code.modules[2][2])
+}
+
+func TestCode_PNG(t *testing.T) {
+	red := color.RGBA{R: 0xFF, A: 0xFF}
+
+	tests := []struct {
+		name     string
+		size     int
+		wantSize int
+	}{
+		{name: "fixed size", size: 256, wantSize: 256},
+		{name: "too small size", size: 10, wantSize: 33},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, err := New("http://localhost:8085/abc123", Options{
+				Size: tt.size, Margin: 2, Foreground: red, Background: white,
+			})
+			require.NoError(t, err)
+
+			buf, err := code.PNG()
+			require.NoError(t, err)
+
+			img, err := png.Decode(bytes.NewReader(buf))
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantSize, img.Bounds().Dx())
+			assert.Equal(t, tt.wantSize, img.Bounds().Dy())
+
+			assert.Equal(t, white, color.RGBAModel.Convert(img.At(0, 0)))
+			scale := tt.wantSize / code.Modules()
+			offset := (tt.wantSize - code.Modules()*scale) / 2
+			assert.Equal(t, red, color.RGBAModel.Convert(img.At(offset+2*scale, offset+2*scale)))
+		})
+	}
+}
+
+func TestCode_SVG(t *testing.T) {
+	code, err := New("http://localhost:8085/abc123", Options{
+		Size: 300, Margin: 4, Foreground: black, Background: white,
+	})
+	require.NoError(t, err)
+
+	svg := string(code.SVG())
+	assert.True(t, strings.HasPrefix(svg, "<svg "))
+	assert.Contains(t, svg, `width="300" height="300" viewBox="0 0 37 37"`)
+	assert.Contains(t, svg, `fill="#ffffff"`)
+	assert.Contains(t, svg, `fill="#000000"`)
+	// the first row of the finder pattern is seven dark modules
+	assert.Contains(t, svg, "M4 4h7v1h-7z")
+}
+
+func TestParseColor(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    color.RGBA
+		wantErr bool
+	}{
+		{in: "#ff8000", want: color.RGBA{R: 0xFF, G: 0x80, A: 0xFF}},
+		{in: "0A0B0C", want: color.RGBA{R: 0x0A, G: 0x0B, B: 0x0C, A: 0xFF}},
+		{in: "#fff", want: white},
+		{in: "red", wantErr: true},
+		{in: "#12345", wantErr: true},
+		{in: "+12345", wantErr: true},
+		{in: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseColor(tt.in)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidColor)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
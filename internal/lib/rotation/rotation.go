@@ -0,0 +1,50 @@
+package rotation
+
+import (
+	"strconv"
+
+	"url-shortener/domain/models"
+)
+
+// Pick chooses a variant with probability proportional to its weight.
+// intn returns a random number in [0, n), e.g. rand.Intn
+func Pick(variants []models.Variant, intn func(n int) int) (models.Variant, bool) {
+	total := 0
+	for _, variant := range variants {
+		if variant.Weight > 0 {
+			total += variant.Weight
+		}
+	}
+	if total == 0 {
+		return models.Variant{}, false
+	}
+
+	roll := intn(total)
+	for _, variant := range variants {
+		if variant.Weight <= 0 {
+			continue
+		}
+		if roll < variant.Weight {
+			return variant, true
+		}
+		roll -= variant.Weight
+	}
+
+	return models.Variant{}, false
+}
+
+// Sticky finds the variant previously assigned to the visitor by its cookie value
+func Sticky(variants []models.Variant, cookie string) (models.Variant, bool) {
+	id, err := strconv.ParseInt(cookie, 10, 64)
+	if err != nil {
+		return models.Variant{}, false
+	}
+
+	for _, variant := range variants {
+		if variant.ID == id && variant.Weight > 0 {
+			return variant, true
+		}
+	}
+
+	return models.Variant{}, false
+}
@@ -0,0 +1,88 @@
+package rotation
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"url-shortener/domain/models"
+)
+
+func TestPick(t *testing.T) {
+	variants := []models.Variant{
+		{ID: 1, URL: "https://a.example.com", Weight: 1},
+		{ID: 2, URL: "https://b.example.com", Weight: 0},
+		{ID: 3, URL: "https://c.example.com", Weight: 3},
+	}
+
+	tests := []struct {
+		name   string
+		roll   int
+		wantID int64
+	}{
+		{name: "first bucket", roll: 0, wantID: 1},
+		{name: "zero weight is skipped", roll: 1, wantID: 3},
+		{name: "last bucket", roll: 3, wantID: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			variant, ok := Pick(variants, func(n int) int {
+				assert.Equal(t, 4, n)
+				return tt.roll
+			})
+			assert.True(t, ok)
+			assert.Equal(t, tt.wantID, variant.ID)
+		})
+	}
+}
+
+func TestPick_Distribution(t *testing.T) {
+	variants := []models.Variant{
+		{ID: 1, Weight: 1},
+		{ID: 2, Weight: 3},
+	}
+
+	rnd := rand.New(rand.NewSource(1))
+	counts := map[int64]int{}
+	for i := 0; i < 10000; i++ {
+		variant, ok := Pick(variants, rnd.Intn)
+		assert.True(t, ok)
+		counts[variant.ID]++
+	}
+
+	assert.InDelta(t, 2500, counts[1], 250)
+	assert.InDelta(t, 7500, counts[2], 250)
+}
+
+func TestPick_NoWeight(t *testing.T) {
+	_, ok := Pick([]models.Variant{{ID: 1}}, rand.Intn)
+	assert.False(t, ok)
+
+	_, ok = Pick(nil, rand.Intn)
+	assert.False(t, ok)
+}
+
+func TestSticky(t *testing.T) {
+	variants := []models.Variant{
+		{ID: 1, Weight: 1},
+		{ID: 2, Weight: 0},
+	}
+
+	tests := []struct {
+		name   string
+		cookie string
+		wantOK bool
+	}{
+		{name: "known variant", cookie: "1", wantOK: true},
+		{name: "disabled variant", cookie: "2"},
+		{name: "unknown variant", cookie: "7"},
+		{name: "garbage", cookie: "abc"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, ok := Sticky(variants, tt.cookie)
+			assert.Equal(t, tt.wantOK, ok)
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package routing
+
+import (
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"url-shortener/domain/models"
+)
+
+// Platforms detected from User-Agent
+const (
+	PlatformIOS     = "ios"
+	PlatformAndroid = "android"
+	PlatformWindows = "windows"
+	PlatformMacOS   = "macos"
+	PlatformLinux   = "linux"
+	PlatformOther   = "other"
+)
+
+// Match returns the first rule whose conditions are all satisfied by the request
+// from the visitor country at the moment now
+func Match(rules []models.Rule, r *http.Request, country string, now time.Time) (models.Rule, bool) {
+	if len(rules) == 0 {
+		return models.Rule{}, false
+	}
+
+	platform := Platform(r.UserAgent())
+	language := Language(r.Header.Get("Accept-Language"))
+	referrer := referrerHost(r.Referer())
+
+	for _, rule := range rules {
+		if rule.Platform != "" && rule.Platform != platform {
+			continue
+		}
+		if rule.Language != "" && !matchLanguage(rule.Language, language) {
+			continue
+		}
+		if !rule.StartsAt.IsZero() && now.Before(rule.StartsAt) {
+			continue
+		}
+		if !rule.EndsAt.IsZero() && !now.Before(rule.EndsAt) {
+			continue
+		}
+		if rule.ReferrerHost != "" && !matchHost(rule.ReferrerHost, referrer) {
+			continue
+		}
+		if rule.Country != "" && !strings.EqualFold(rule.Country, country) {
+			continue
+		}
+		return rule, true
+	}
+
+	return models.Rule{}, false
+}
+
+// Platform detects the visitor platform from User-Agent
+func Platform(userAgent string) string {
+	switch {
+	case strings.Contains(userAgent, "iPhone"),
+		strings.Contains(userAgent, "iPad"),
+		strings.Contains(userAgent, "iPod"):
+		return PlatformIOS
+	case strings.Contains(userAgent, "Android"):
+		return PlatformAndroid
+	case strings.Contains(userAgent, "Windows"):
+		return PlatformWindows
+	case strings.Contains(userAgent, "Macintosh"), strings.Contains(userAgent, "Mac OS X"):
+		return PlatformMacOS
+	case strings.Contains(userAgent, "Linux"):
+		return PlatformLinux
+	default:
+		return PlatformOther
+	}
+}
+
+// Language returns the most preferred language tag from Accept-Language header in lower case
+func Language(acceptLanguage string) string {
+	type tag struct {
+		name string
+		q    float64
+	}
+
+	var tags []tag
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if name == "" || name == "*" {
+			continue
+		}
+
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if q <= 0 {
+			continue
+		}
+
+		tags = append(tags, tag{name: strings.ToLower(name), q: q})
+	}
+	if len(tags) == 0 {
+		return ""
+	}
+
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].q > tags[j].q })
+
+	return tags[0].name
+}
+
+// matchLanguage reports whether the language tag is the rule language or its regional variant
+func matchLanguage(rule, language string) bool {
+	rule = strings.ToLower(rule)
+	return language == rule || strings.HasPrefix(language, rule+"-")
+}
+
+// matchHost reports whether host is the rule host or its subdomain
+func matchHost(rule, host string) bool {
+	rule = strings.ToLower(rule)
+	return host == rule || strings.HasSuffix(host, "."+rule)
+}
+
+func referrerHost(referrer string) string {
+	u, err := url.Parse(referrer)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Hostname())
+}
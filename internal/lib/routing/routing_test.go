@@ -0,0 +1,152 @@
+package routing
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"url-shortener/domain/models"
+)
+
+const (
+	uaIPhone  = "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) AppleWebKit/605.1.15 Mobile/15E148"
+	uaAndroid = "Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 Chrome/120.0 Mobile Safari/537.36"
+	uaMac     = "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 Version/17.0 Safari/605.1.15"
+	uaWindows = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 Chrome/120.0 Safari/537.36"
+	uaLinux   = "Mozilla/5.0 (X11; Linux x86_64; rv:121.0) Gecko/20100101 Firefox/121.0"
+)
+
+func TestPlatform(t *testing.T) {
+	tests := []struct {
+		userAgent string
+		want      string
+	}{
+		{userAgent: uaIPhone, want: PlatformIOS},
+		{userAgent: uaAndroid, want: PlatformAndroid},
+		{userAgent: uaMac, want: PlatformMacOS},
+		{userAgent: uaWindows, want: PlatformWindows},
+		{userAgent: uaLinux, want: PlatformLinux},
+		{userAgent: "curl/8.0", want: PlatformOther},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			assert.Equal(t, tt.want, Platform(tt.userAgent))
+		})
+	}
+}
+
+func TestLanguage(t *testing.T) {
+	tests := []struct {
+		name           string
+		acceptLanguage string
+		want           string
+	}{
+		{name: "empty", acceptLanguage: "", want: ""},
+		{name: "single", acceptLanguage: "ru-RU", want: "ru-ru"},
+		{name: "ordered by q", acceptLanguage: "en;q=0.5, de-DE;q=0.9, fr;q=0.1", want: "de-de"},
+		{name: "first without q", acceptLanguage: "ru, en;q=0.9", want: "ru"},
+		{name: "wildcard and zero q", acceptLanguage: "*, es;q=0, it;q=0.3", want: "it"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Language(tt.acceptLanguage))
+		})
+	}
+}
+
+func TestMatch(t *testing.T) {
+	launch := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+
+	rules := []models.Rule{
+		{Platform: PlatformIOS, URL: "https://apps.apple.com/app"},
+		{Platform: PlatformAndroid, URL: "https://play.google.com/store/apps"},
+		{ReferrerHost: "twitter.com", URL: "https://example.com/from-twitter"},
+		{Country: "FR", URL: "https://example.fr"},
+		{Language: "de", URL: "https://example.de"},
+		{StartsAt: launch, URL: "https://example.com/launched"},
+	}
+
+	tests := []struct {
+		name      string
+		userAgent string
+		language  string
+		referrer  string
+		country   string
+		now       time.Time
+		want      string
+	}{
+		{
+			name:      "ios",
+			userAgent: uaIPhone,
+			now:       launch,
+			want:      "https://apps.apple.com/app",
+		},
+		{
+			name:      "android",
+			userAgent: uaAndroid,
+			now:       launch,
+			want:      "https://play.google.com/store/apps",
+		},
+		{
+			name:      "referrer subdomain",
+			userAgent: uaWindows,
+			referrer:  "https://mobile.twitter.com/post/1",
+			now:       launch,
+			want:      "https://example.com/from-twitter",
+		},
+		{
+			name:      "country",
+			userAgent: uaWindows,
+			country:   "FR",
+			now:       launch,
+			want:      "https://example.fr",
+		},
+		{
+			name:      "language variant",
+			userAgent: uaWindows,
+			language:  "de-AT, en;q=0.8",
+			now:       launch,
+			want:      "https://example.de",
+		},
+		{
+			name:      "after launch",
+			userAgent: uaMac,
+			now:       launch.Add(time.Hour),
+			want:      "https://example.com/launched",
+		},
+		{
+			name:      "fallback before launch",
+			userAgent: uaMac,
+			now:       launch.Add(-time.Hour),
+			want:      "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/alias", nil)
+			r.Header.Set("User-Agent", tt.userAgent)
+			r.Header.Set("Accept-Language", tt.language)
+			r.Header.Set("Referer", tt.referrer)
+
+			rule, ok := Match(rules, r, tt.country, tt.now)
+			assert.Equal(t, tt.want != "", ok)
+			assert.Equal(t, tt.want, rule.URL)
+		})
+	}
+}
+
+func TestMatch_TimeWindow(t *testing.T) {
+	start := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(24 * time.Hour)
+	rules := []models.Rule{{StartsAt: start, EndsAt: end, URL: "https://example.com/sale"}}
+
+	r := httptest.NewRequest(http.MethodGet, "/alias", nil)
+
+	_, ok := Match(rules, r, "", start)
+	assert.True(t, ok)
+	_, ok = Match(rules, r, "", end)
+	assert.False(t, ok)
+}
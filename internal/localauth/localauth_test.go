@@ -0,0 +1,133 @@
+package localauth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/domain/models"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/storage"
+)
+
+var errUnavailable = errors.New("database is locked")
+
+type users map[string]models.User
+
+func (u users) User(email string) (models.User, error) {
+	if email == "broken@example.com" {
+		return models.User{}, errUnavailable
+	}
+	user, ok := u[email]
+	if !ok {
+		return models.User{}, storage.ErrUserNotFound
+	}
+	return user, nil
+}
+
+type issuer struct {
+	err error
+}
+
+func (i issuer) Issue(uid int64, email string, level int8, _ []string) (string, time.Time, error) {
+	if i.err != nil {
+		return "", time.Time{}, i.err
+	}
+	return fmt.Sprintf("%s/%d/%d", email, uid, level), time.Unix(1, 0), nil
+}
+
+func newUsers(t *testing.T) users {
+	t.Helper()
+
+	hash, err := HashPassword("correct horse")
+	require.NoError(t, err)
+
+	return users{
+		"user@example.com":  {ID: 1, Email: "user@example.com", PassHash: hash, Level: 1},
+		"admin@example.com": {ID: 2, Email: "admin@example.com", PassHash: hash, Level: 2},
+	}
+}
+
+func TestAuth_Login(t *testing.T) {
+	a, err := New(slogdiscard.NewDiscardLogger(), newUsers(t), issuer{})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		email    string
+		password string
+		want     string
+		wantErr  error
+	}{
+		{name: "success", email: "user@example.com", password: "correct horse", want: "user@example.com/1/1"},
+		{name: "email is normalized", email: " Admin@Example.com", password: "correct horse", want: "admin@example.com/2/2"},
+		{name: "wrong password", email: "user@example.com", password: "wrong horse", wantErr: ErrInvalidCredentials},
+		{name: "unknown user", email: "nobody@example.com", password: "correct horse", wantErr: ErrInvalidCredentials},
+		{name: "storage error", email: "broken@example.com", password: "correct horse", wantErr: errUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, expiresAt, err := a.Login(context.Background(), tt.email, tt.password)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, token)
+			assert.Equal(t, time.Unix(1, 0), expiresAt)
+		})
+	}
+
+	failing, err := New(slogdiscard.NewDiscardLogger(), newUsers(t), issuer{err: errUnavailable})
+	require.NoError(t, err)
+	_, _, err = failing.Login(context.Background(), "user@example.com", "correct horse")
+	assert.ErrorIs(t, err, errUnavailable)
+}
+
+func TestAuth_IsAdmin(t *testing.T) {
+	a, err := New(slogdiscard.NewDiscardLogger(), newUsers(t), issuer{})
+	require.NoError(t, err)
+
+	tests := []struct {
+		email   string
+		want    bool
+		wantErr error
+	}{
+		{email: "admin@example.com", want: true},
+		{email: "ADMIN@example.com", want: true},
+		{email: "user@example.com", want: false},
+		{email: "nobody@example.com", want: false},
+		{email: "broken@example.com", wantErr: errUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.email, func(t *testing.T) {
+			isAdmin, err := a.IsAdmin(context.Background(), tt.email)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, isAdmin)
+		})
+	}
+}
+
+func TestHashPassword(t *testing.T) {
+	_, err := HashPassword("short")
+	assert.ErrorIs(t, err, ErrInvalidPassword)
+	_, err = HashPassword(strings.Repeat("p", MaxPasswordLength+1))
+	assert.ErrorIs(t, err, ErrInvalidPassword)
+
+	first, err := HashPassword("correct horse")
+	require.NoError(t, err)
+	second, err := HashPassword("correct horse")
+	require.NoError(t, err)
+	assert.NotEqual(t, first, second, "hashes must be salted")
+	assert.NotContains(t, string(first), "correct horse")
+}
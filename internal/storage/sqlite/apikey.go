@@ -0,0 +1,134 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+
+	"url-shortener/domain/models"
+	"url-shortener/internal/storage"
+)
+
+const apiKeyColumns = "id, uid, email, name, prefix, hash, scopes, expires_at, last_used_at, created_at"
+
+// SaveAPIKey saves API key with name unique for the user to db
+func (s *Storage) SaveAPIKey(key models.APIKey) (int64, error) {
+	const op = "storage.sqlite.SaveAPIKey"
+
+	res, err := s.db.Exec(`
+		INSERT INTO api_key(uid, email, name, prefix, hash, scopes, expires_at, created_at)
+		VALUES(?, ?, ?, ?, ?, ?, ?, ?)`,
+		key.UID, key.Email, key.Name, key.Prefix, key.Hash, strings.Join(key.Scopes, " "),
+		nullTime(key.ExpiresAt), time.Now().UTC(),
+	)
+	if err != nil {
+		var sqliteErr sqlite3.Error
+		if errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique {
+			return 0, fmt.Errorf("%s: %w", op, storage.ErrAPIKeyExists)
+		}
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
+
+// APIKey gets API key by the hash of its value from db
+func (s *Storage) APIKey(hash []byte) (models.APIKey, error) {
+	const op = "storage.sqlite.APIKey"
+
+	key, err := scanAPIKey(s.db.QueryRow("SELECT "+apiKeyColumns+" FROM api_key WHERE hash = ?", hash))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.APIKey{}, fmt.Errorf("%s: %w", op, storage.ErrAPIKeyNotFound)
+		}
+		return models.APIKey{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return key, nil
+}
+
+// APIKeys gets API keys of the user ordered by name from db
+func (s *Storage) APIKeys(uid int64) ([]models.APIKey, error) {
+	const op = "storage.sqlite.APIKeys"
+
+	rows, err := s.db.Query("SELECT "+apiKeyColumns+" FROM api_key WHERE uid = ? ORDER BY name", uid)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	keys := []models.APIKey{}
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return keys, nil
+}
+
+// DeleteAPIKey deletes API key of the user from db, zero uid deletes the key of any user
+func (s *Storage) DeleteAPIKey(id, uid int64) error {
+	const op = "storage.sqlite.DeleteAPIKey"
+
+	res, err := s.db.Exec("DELETE FROM api_key WHERE id = ? AND (? = 0 OR uid = ?)", id, uid, uid)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrAPIKeyNotFound)
+	}
+
+	return nil
+}
+
+// TouchAPIKey sets the time API key was last used at
+func (s *Storage) TouchAPIKey(id int64, usedAt time.Time) error {
+	const op = "storage.sqlite.TouchAPIKey"
+
+	_, err := s.db.Exec("UPDATE api_key SET last_used_at = ? WHERE id = ?", usedAt.UTC(), id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func scanAPIKey(row interface{ Scan(dest ...any) error }) (models.APIKey, error) {
+	var (
+		key        models.APIKey
+		scopes     string
+		expiresAt  sql.NullTime
+		lastUsedAt sql.NullTime
+	)
+	err := row.Scan(&key.ID, &key.UID, &key.Email, &key.Name, &key.Prefix, &key.Hash, &scopes,
+		&expiresAt, &lastUsedAt, &key.CreatedAt)
+	if err != nil {
+		return models.APIKey{}, err
+	}
+
+	key.Scopes = strings.Fields(scopes)
+	key.ExpiresAt = expiresAt.Time
+	key.LastUsedAt = lastUsedAt.Time
+
+	return key, nil
+}
@@ -0,0 +1,80 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"url-shortener/domain/models"
+)
+
+// SaveClick saves the link visit and increments click counter of its variant
+func (s *Storage) SaveClick(click models.Click) error {
+	const op = "storage.sqlite.SaveClick"
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var variantID sql.NullInt64
+	if click.VariantID != 0 {
+		variantID = sql.NullInt64{Int64: click.VariantID, Valid: true}
+	}
+
+	createdAt := click.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = time.Now()
+	}
+
+	_, err = tx.Exec(
+		"INSERT INTO click(url_id, variant_id, country, created_at) VALUES(?, ?, ?, ?)",
+		click.URLID, variantID, click.Country, createdAt.UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("%s: execute statement %w", op, err)
+	}
+
+	if variantID.Valid {
+		_, err = tx.Exec("UPDATE url_variant SET clicks = clicks + 1 WHERE id = ?", variantID)
+		if err != nil {
+			return fmt.Errorf("%s: execute statement %w", op, err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// ClicksByCountry counts visits of the link grouped by visitor country,
+// visits from unknown locations are counted under empty key
+func (s *Storage) ClicksByCountry(urlID int64) (map[string]int64, error) {
+	const op = "storage.sqlite.ClicksByCountry"
+
+	rows, err := s.db.Query("SELECT country, COUNT(*) FROM click WHERE url_id = ? GROUP BY country", urlID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	clicks := make(map[string]int64)
+	for rows.Next() {
+		var (
+			country string
+			count   int64
+		)
+		if err = rows.Scan(&country, &count); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		clicks[country] = count
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return clicks, nil
+}
@@ -0,0 +1,79 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"url-shortener/domain/models"
+)
+
+// saveRules saves routing rules of the URL keeping their order
+func saveRules(tx *sql.Tx, urlID int64, rules []models.Rule) error {
+	const op = "storage.sqlite.saveRules"
+
+	if len(rules) == 0 {
+		return nil
+	}
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO url_rule(url_id, position, platform, language, starts_at, ends_at, referrer_host, country, url)
+		VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() { _ = stmt.Close() }()
+
+	for i, rule := range rules {
+		_, err = stmt.Exec(
+			urlID, i, rule.Platform, rule.Language,
+			nullTime(rule.StartsAt), nullTime(rule.EndsAt), rule.ReferrerHost, rule.Country, rule.URL,
+		)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return nil
+}
+
+// rules gets routing rules of the URL in evaluation order
+func (s *Storage) rules(urlID int64) ([]models.Rule, error) {
+	const op = "storage.sqlite.rules"
+
+	rows, err := s.db.Query(`
+		SELECT id, platform, language, starts_at, ends_at, referrer_host, country, url
+		FROM url_rule WHERE url_id = ? ORDER BY position`, urlID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var rules []models.Rule
+	for rows.Next() {
+		var (
+			rule             models.Rule
+			startsAt, endsAt sql.NullTime
+		)
+		err = rows.Scan(
+			&rule.ID, &rule.Platform, &rule.Language, &startsAt, &endsAt, &rule.ReferrerHost, &rule.Country, &rule.URL,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		rule.StartsAt, rule.EndsAt = startsAt.Time, endsAt.Time
+		rules = append(rules, rule)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return rules, nil
+}
+
+func nullTime(t time.Time) sql.NullTime {
+	if t.IsZero() {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: t.UTC(), Valid: true}
+}
@@ -4,19 +4,29 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/mattn/go-sqlite3"
 
 	"url-shortener/domain/models"
+	"url-shortener/internal/lib/aead"
 	"url-shortener/internal/storage"
 )
 
 type Storage struct {
-	db *sql.DB
+	db     *sql.DB
+	sealer Sealer
 }
 
-// New creates new instance of the SQLite storage
-func New(storagePath string) (*Storage, error) {
+// Sealer encrypts the secrets kept in the database, the additional data binds a value to its row and column
+type Sealer interface {
+	Seal(plaintext, ad []byte) (string, error)
+	Open(value string, ad []byte) ([]byte, error)
+}
+
+// New creates new instance of the SQLite storage, client credentials are encrypted with the sealer.
+// The sealer may be nil if client credentials are not used, they fail with storage.ErrNoSealer then
+func New(storagePath string, sealer Sealer) (*Storage, error) {
 	const op = "storage.sqlite.New"
 
 	db, err := sql.Open("sqlite3", storagePath)
@@ -24,18 +34,23 @@ func New(storagePath string) (*Storage, error) {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	return &Storage{db: db}, nil
+	return &Storage{db: db, sealer: sealer}, nil
 }
 
 func (s *Storage) SaveClient(name, apiKey, userKey string) error {
 	const op = "storage.sqlite.SaveClient"
 
+	sealedAPIKey, sealedUserKey, err := s.sealClient(name, apiKey, userKey)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
 	stmt, err := s.db.Prepare("INSERT INTO client(name, apiKey, userKey) VALUES(?, ?, ?)")
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
-	_, err = stmt.Exec(name, apiKey, userKey)
+	_, err = stmt.Exec(name, sealedAPIKey, sealedUserKey)
 	if err != nil {
 		var sqliteErr sqlite3.Error
 		if errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique {
@@ -47,6 +62,7 @@ func (s *Storage) SaveClient(name, apiKey, userKey string) error {
 	return nil
 }
 
+// Client returns the client with decrypted credentials, they are never stored in plaintext
 func (s *Storage) Client(name string) (models.Client, error) {
 	const op = "storage.sqlite.Client"
 
@@ -66,19 +82,143 @@ func (s *Storage) Client(name string) (models.Client, error) {
 		return models.Client{}, fmt.Errorf("%s: %w", op, err)
 	}
 
+	if !aead.IsSealed(client.ApiKey) || !aead.IsSealed(client.UserKey) {
+		return models.Client{}, fmt.Errorf("%s: %w", op, storage.ErrClientNotEncrypted)
+	}
+
+	client.ApiKey, client.UserKey, err = s.openClient(client.Name, client.ApiKey, client.UserKey)
+	if err != nil {
+		return models.Client{}, fmt.Errorf("%s: %w", op, err)
+	}
+
 	return client, nil
 }
 
-// SaveURL saves URL and alias to db
-func (s *Storage) SaveURL(urlToSave string, alias string) error {
+// ReencryptClients seals the credentials of all clients with the current key of the sealer,
+// plaintext credentials stored by older versions are encrypted as well. It returns the number of clients
+func (s *Storage) ReencryptClients() (int, error) {
+	const op = "storage.sqlite.ReencryptClients"
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	rows, err := tx.Query("SELECT id, name, apiKey, userKey FROM client")
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var clients []models.Client
+	for rows.Next() {
+		var client models.Client
+		if err := rows.Scan(&client.ID, &client.Name, &client.ApiKey, &client.UserKey); err != nil {
+			_ = rows.Close()
+			return 0, fmt.Errorf("%s: %w", op, err)
+		}
+		clients = append(clients, client)
+	}
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	_ = rows.Close()
+
+	stmt, err := tx.Prepare("UPDATE client SET apiKey = ?, userKey = ? WHERE id = ?")
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	for _, client := range clients {
+		apiKey, userKey := client.ApiKey, client.UserKey
+		if aead.IsSealed(apiKey) || aead.IsSealed(userKey) {
+			apiKey, userKey, err = s.openClient(client.Name, apiKey, userKey)
+			if err != nil {
+				return 0, fmt.Errorf("%s: client %s: %w", op, client.Name, err)
+			}
+		}
+
+		sealedAPIKey, sealedUserKey, err := s.sealClient(client.Name, apiKey, userKey)
+		if err != nil {
+			return 0, fmt.Errorf("%s: %w", op, err)
+		}
+
+		if _, err := stmt.Exec(sealedAPIKey, sealedUserKey, client.ID); err != nil {
+			return 0, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return len(clients), nil
+}
+
+func (s *Storage) sealClient(name, apiKey, userKey string) (string, string, error) {
+	if s.sealer == nil {
+		return "", "", storage.ErrNoSealer
+	}
+
+	sealedAPIKey, err := s.sealer.Seal([]byte(apiKey), clientAD(name, "apiKey"))
+	if err != nil {
+		return "", "", err
+	}
+	sealedUserKey, err := s.sealer.Seal([]byte(userKey), clientAD(name, "userKey"))
+	if err != nil {
+		return "", "", err
+	}
+
+	return sealedAPIKey, sealedUserKey, nil
+}
+
+func (s *Storage) openClient(name, apiKey, userKey string) (string, string, error) {
+	if s.sealer == nil {
+		return "", "", storage.ErrNoSealer
+	}
+
+	openedAPIKey, err := s.sealer.Open(apiKey, clientAD(name, "apiKey"))
+	if err != nil {
+		return "", "", err
+	}
+	openedUserKey, err := s.sealer.Open(userKey, clientAD(name, "userKey"))
+	if err != nil {
+		return "", "", err
+	}
+
+	return string(openedAPIKey), string(openedUserKey), nil
+}
+
+// clientAD keeps a credential from being decrypted after it is moved to another client or column
+func clientAD(name, column string) []byte {
+	return []byte("client:" + name + ":" + column)
+}
+
+// SaveURL saves URL and alias with redirect settings, routing rules and variants to db
+func (s *Storage) SaveURL(url models.URL) error {
 	const op = "storage.sqlite.SaveURL"
 
-	stmt, err := s.db.Prepare("INSERT INTO url(url, alias) VALUES(?, ?)")
+	tx, err := s.db.Begin()
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
+	defer func() { _ = tx.Rollback() }()
+
+	var utmTemplateID, workspaceID sql.NullInt64
+	if url.UTMTemplate != nil {
+		utmTemplateID = sql.NullInt64{Int64: url.UTMTemplate.ID, Valid: true}
+	}
+	if url.Workspace != nil {
+		workspaceID = sql.NullInt64{Int64: url.Workspace.ID, Valid: true}
+	}
 
-	_, err = stmt.Exec(urlToSave, alias)
+	res, err := tx.Exec(`
+		INSERT INTO url(url, domain, alias, redirect_code, cache_max_age, passthrough, query_conflict,
+		                utm_template_id, workspace_id, owner_uid, created_at)
+		VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		url.URL, url.Domain, url.Alias, url.RedirectCode, url.CacheMaxAge, url.Passthrough, url.QueryConflict,
+		utmTemplateID, workspaceID, url.OwnerUID, time.Now().UTC(),
+	)
 	if err != nil {
 		var sqliteErr sqlite3.Error
 		if errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique {
@@ -87,20 +227,37 @@ func (s *Storage) SaveURL(urlToSave string, alias string) error {
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
+	urlID, err := res.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err = saveRules(tx, urlID, url.Rules); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err = saveVariants(tx, urlID, url.Variants); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
 	return nil
 }
 
-// GetURL gets URL by alias from db
-func (s *Storage) GetURL(alias string) (string, error) {
+// GetURL gets URL by domain and alias from db
+func (s *Storage) GetURL(domain, alias string) (string, error) {
 	const op = "storage.sqlite.GetURL"
 
-	stmt, err := s.db.Prepare("SELECT url FROM url WHERE alias = ?")
+	stmt, err := s.db.Prepare("SELECT url FROM url WHERE domain = ? AND alias = ?")
 	if err != nil {
 		return "", fmt.Errorf("%s: %w", op, err)
 	}
 
 	var resURL string
-	err = stmt.QueryRow(alias).Scan(&resURL)
+	err = stmt.QueryRow(domain, alias).Scan(&resURL)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return "", storage.ErrURLNotFound
@@ -111,23 +268,190 @@ func (s *Storage) GetURL(alias string) (string, error) {
 	return resURL, nil
 }
 
-// DeleteURL deletes URL by alias from db
-func (s *Storage) DeleteURL(alias string) error {
-	const op = "storage.sqlite.DeleteURL"
+// AliasExists reports whether the domain has an alias equal to the given one under the fold.
+// Replacements are nested in the query, so they must not chain into each other
+func (s *Storage) AliasExists(domain, alias string, fold storage.AliasFold) (bool, error) {
+	const op = "storage.sqlite.AliasExists"
 
-	stmt, err := s.db.Prepare("DELETE FROM url WHERE alias = ?")
+	column := "alias"
+	args := []any{domain}
+	for i := 0; i+1 < len(fold.Replace); i += 2 {
+		column = fmt.Sprintf("replace(%s, ?, ?)", column)
+		args = append(args, fold.Replace[i], fold.Replace[i+1])
+	}
+	if fold.IgnoreCase {
+		column = fmt.Sprintf("lower(%s)", column)
+	}
+	args = append(args, fold.Apply(alias))
+
+	var exists bool
+	err := s.db.QueryRow(
+		fmt.Sprintf("SELECT EXISTS(SELECT 1 FROM url WHERE domain = ? AND %s = ?)", column),
+		args...,
+	).Scan(&exists)
 	if err != nil {
-		return fmt.Errorf("%s: %w", op, err)
+		return false, fmt.Errorf("%s: %w", op, err)
 	}
 
-	_, err = stmt.Exec(alias)
+	return exists, nil
+}
+
+// URL gets URL record with its metadata, workspace, UTM template, rules and variants by domain and alias from db
+func (s *Storage) URL(domain, alias string) (models.URL, error) {
+	const op = "storage.sqlite.URL"
+
+	stmt, err := s.db.Prepare(`
+		SELECT u.id, u.domain, u.alias, u.url, u.redirect_code, u.cache_max_age, u.passthrough, u.query_conflict, u.owner_uid, u.created_at,
+		       t.id, t.name, t.source, t.medium, t.campaign, t.term, t.content,
+		       w.id, w.name, w.created_at
+		FROM url u
+		LEFT JOIN utm_template t ON t.id = u.utm_template_id
+		LEFT JOIN workspace w ON w.id = u.workspace_id
+		WHERE u.domain = ? AND u.alias = ?`)
+	if err != nil {
+		return models.URL{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var (
+		url       models.URL
+		createdAt sql.NullTime
+		tmplID    sql.NullInt64
+		tmpl      [6]sql.NullString
+		ws        nullWorkspace
+	)
+	err = stmt.QueryRow(domain, alias).Scan(
+		&url.ID, &url.Domain, &url.Alias, &url.URL, &url.RedirectCode, &url.CacheMaxAge, &url.Passthrough, &url.QueryConflict, &url.OwnerUID, &createdAt,
+		&tmplID, &tmpl[0], &tmpl[1], &tmpl[2], &tmpl[3], &tmpl[4], &tmpl[5],
+		&ws.id, &ws.name, &ws.createdAt,
+	)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return storage.ErrURLNotFound
+			return models.URL{}, storage.ErrURLNotFound
+		}
+		return models.URL{}, fmt.Errorf("%s: execute statement %w", op, err)
+	}
+	url.CreatedAt = createdAt.Time
+	url.Workspace = ws.workspace()
+
+	url.Rules, err = s.rules(url.ID)
+	if err != nil {
+		return models.URL{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	url.Variants, err = s.variants(url.ID)
+	if err != nil {
+		return models.URL{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if tmplID.Valid {
+		url.UTMTemplate = &models.UTMTemplate{
+			ID:       tmplID.Int64,
+			Name:     tmpl[0].String,
+			Source:   tmpl[1].String,
+			Medium:   tmpl[2].String,
+			Campaign: tmpl[3].String,
+			Term:     tmpl[4].String,
+			Content:  tmpl[5].String,
 		}
+	}
+
+	return url, nil
+}
+
+// URLs gets links matching the filter ordered by creation from db,
+// rules and variants are not loaded
+func (s *Storage) URLs(filter storage.URLFilter) ([]models.URL, error) {
+	const op = "storage.sqlite.URLs"
+
+	query := `
+		SELECT u.id, u.domain, u.alias, u.url, u.redirect_code, u.owner_uid, u.created_at,
+		       w.id, w.name, w.created_at
+		FROM url u
+		LEFT JOIN workspace w ON w.id = u.workspace_id
+		WHERE 1 = 1`
+	var args []any
+	if filter.WorkspaceID != 0 {
+		query += " AND u.workspace_id = ?"
+		args = append(args, filter.WorkspaceID)
+	}
+	if filter.UID != 0 {
+		query += " AND (u.owner_uid = ? OR u.workspace_id IN (SELECT workspace_id FROM workspace_member WHERE uid = ?))"
+		args = append(args, filter.UID, filter.UID)
+	}
+	query += " ORDER BY u.id"
+	if filter.Limit > 0 {
+		query += " LIMIT ? OFFSET ?"
+		args = append(args, filter.Limit, filter.Offset)
+	}
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var urls []models.URL
+	for rows.Next() {
+		var (
+			url       models.URL
+			createdAt sql.NullTime
+			ws        nullWorkspace
+		)
+		err = rows.Scan(
+			&url.ID, &url.Domain, &url.Alias, &url.URL, &url.RedirectCode, &url.OwnerUID, &createdAt,
+			&ws.id, &ws.name, &ws.createdAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		url.CreatedAt = createdAt.Time
+		url.Workspace = ws.workspace()
+		urls = append(urls, url)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return urls, nil
+}
+
+// DeleteURL deletes URL with its routing rules, variants and clicks by domain and alias from db
+func (s *Storage) DeleteURL(domain, alias string) error {
+	const op = "storage.sqlite.DeleteURL"
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	for _, table := range []string{"click", "url_rule", "url_variant"} {
+		_, err = tx.Exec(
+			"DELETE FROM "+table+" WHERE url_id IN (SELECT id FROM url WHERE domain = ? AND alias = ?)",
+			domain, alias,
+		)
+		if err != nil {
+			return fmt.Errorf("%s: execute statement %w", op, err)
+		}
+	}
+
+	res, err := tx.Exec("DELETE FROM url WHERE domain = ? AND alias = ?", domain, alias)
+	if err != nil {
 		return fmt.Errorf("%s: execute statement %w", op, err)
 	}
 
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrURLNotFound)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
 	return nil
 }
 
@@ -0,0 +1,102 @@
+package sqlite
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/domain/models"
+	"url-shortener/internal/storage"
+)
+
+// newTestStorage opens a storage in a temporary file with all up migrations applied
+func newTestStorage(t *testing.T) *Storage {
+	t.Helper()
+
+	s, err := New(filepath.Join(t.TempDir(), "storage.db"), nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = s.Close() })
+
+	files, err := filepath.Glob(filepath.Join("..", "..", "..", "migrations", "*.up.sql"))
+	require.NoError(t, err)
+	require.NotEmpty(t, files)
+
+	version := func(file string) int {
+		v, err := strconv.Atoi(strings.SplitN(filepath.Base(file), "_", 2)[0])
+		require.NoError(t, err)
+		return v
+	}
+	sort.Slice(files, func(i, j int) bool { return version(files[i]) < version(files[j]) })
+
+	for _, file := range files {
+		query, err := os.ReadFile(file)
+		require.NoError(t, err)
+		_, err = s.db.Exec(string(query))
+		require.NoError(t, err, file)
+	}
+
+	return s
+}
+
+func TestStorage_DeleteURL(t *testing.T) {
+	s := newTestStorage(t)
+
+	require.NoError(t, s.SaveURL(models.URL{
+		URL:          "https://google.com",
+		Domain:       "sho.rt",
+		Alias:        "google",
+		RedirectCode: 302,
+		CacheMaxAge:  -1,
+	}))
+
+	cases := []struct {
+		name    string
+		domain  string
+		alias   string
+		wantErr error
+	}{
+		{
+			name:   "Success",
+			domain: "sho.rt",
+			alias:  "google",
+		},
+		{
+			name:    "Deleted alias",
+			domain:  "sho.rt",
+			alias:   "google",
+			wantErr: storage.ErrURLNotFound,
+		},
+		{
+			name:    "Missing alias",
+			domain:  "sho.rt",
+			alias:   "missing",
+			wantErr: storage.ErrURLNotFound,
+		},
+		{
+			name:    "Alias of another domain",
+			domain:  "other.rt",
+			alias:   "google",
+			wantErr: storage.ErrURLNotFound,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := s.DeleteURL(tc.domain, tc.alias)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+
+			_, err = s.URL(tc.domain, tc.alias)
+			assert.ErrorIs(t, err, storage.ErrURLNotFound)
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+
+	"url-shortener/domain/models"
+	"url-shortener/internal/storage"
+)
+
+// SaveUser saves user with unique email and password hash to db
+func (s *Storage) SaveUser(email string, passHash []byte, level int8) (int64, error) {
+	const op = "storage.sqlite.SaveUser"
+
+	res, err := s.db.Exec(
+		"INSERT INTO user(email, pass_hash, level, created_at) VALUES(?, ?, ?, ?)",
+		email, passHash, level, time.Now().UTC(),
+	)
+	if err != nil {
+		var sqliteErr sqlite3.Error
+		if errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique {
+			return 0, fmt.Errorf("%s: %w", op, storage.ErrUserExists)
+		}
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
+
+// User gets user by email from db
+func (s *Storage) User(email string) (models.User, error) {
+	const op = "storage.sqlite.User"
+
+	var user models.User
+	err := s.db.QueryRow("SELECT id, email, pass_hash, level, created_at FROM user WHERE email = ?", email).
+		Scan(&user.ID, &user.Email, &user.PassHash, &user.Level, &user.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.User{}, fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
+		}
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return user, nil
+}
@@ -0,0 +1,127 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/mattn/go-sqlite3"
+
+	"url-shortener/domain/models"
+	"url-shortener/internal/storage"
+)
+
+// SaveUTMTemplate saves named UTM template to db
+func (s *Storage) SaveUTMTemplate(tmpl models.UTMTemplate) error {
+	const op = "storage.sqlite.SaveUTMTemplate"
+
+	stmt, err := s.db.Prepare(`
+		INSERT INTO utm_template(name, source, medium, campaign, term, content)
+		VALUES(?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = stmt.Exec(tmpl.Name, tmpl.Source, tmpl.Medium, tmpl.Campaign, tmpl.Term, tmpl.Content)
+	if err != nil {
+		var sqliteErr sqlite3.Error
+		if errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique {
+			return fmt.Errorf("%s: %w", op, storage.ErrUTMTemplateExists)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// UTMTemplate gets UTM template by name from db
+func (s *Storage) UTMTemplate(name string) (models.UTMTemplate, error) {
+	const op = "storage.sqlite.UTMTemplate"
+
+	stmt, err := s.db.Prepare(`
+		SELECT id, name, source, medium, campaign, term, content
+		FROM utm_template WHERE name = ?`)
+	if err != nil {
+		return models.UTMTemplate{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var tmpl models.UTMTemplate
+	err = stmt.QueryRow(name).Scan(
+		&tmpl.ID, &tmpl.Name, &tmpl.Source, &tmpl.Medium, &tmpl.Campaign, &tmpl.Term, &tmpl.Content,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.UTMTemplate{}, fmt.Errorf("%s: %w", op, storage.ErrUTMTemplateNotFound)
+		}
+		return models.UTMTemplate{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return tmpl, nil
+}
+
+// UTMTemplates gets all UTM templates ordered by name from db
+func (s *Storage) UTMTemplates() ([]models.UTMTemplate, error) {
+	const op = "storage.sqlite.UTMTemplates"
+
+	rows, err := s.db.Query(`
+		SELECT id, name, source, medium, campaign, term, content
+		FROM utm_template ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var templates []models.UTMTemplate
+	for rows.Next() {
+		var tmpl models.UTMTemplate
+		err = rows.Scan(&tmpl.ID, &tmpl.Name, &tmpl.Source, &tmpl.Medium, &tmpl.Campaign, &tmpl.Term, &tmpl.Content)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		templates = append(templates, tmpl)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return templates, nil
+}
+
+// DeleteUTMTemplate deletes UTM template by name from db if no link uses it
+func (s *Storage) DeleteUTMTemplate(name string) error {
+	const op = "storage.sqlite.DeleteUTMTemplate"
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var id int64
+	err = tx.QueryRow("SELECT id FROM utm_template WHERE name = ?", name).Scan(&id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%s: %w", op, storage.ErrUTMTemplateNotFound)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	var inUse bool
+	err = tx.QueryRow("SELECT EXISTS(SELECT 1 FROM url WHERE utm_template_id = ?)", id).Scan(&inUse)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if inUse {
+		return fmt.Errorf("%s: %w", op, storage.ErrUTMTemplateInUse)
+	}
+
+	if _, err = tx.Exec("DELETE FROM utm_template WHERE id = ?", id); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,58 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+
+	"url-shortener/domain/models"
+)
+
+// saveVariants saves weighted destinations of the URL keeping their order
+func saveVariants(tx *sql.Tx, urlID int64, variants []models.Variant) error {
+	const op = "storage.sqlite.saveVariants"
+
+	if len(variants) == 0 {
+		return nil
+	}
+
+	stmt, err := tx.Prepare("INSERT INTO url_variant(url_id, position, url, weight) VALUES(?, ?, ?, ?)")
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() { _ = stmt.Close() }()
+
+	for i, variant := range variants {
+		if _, err = stmt.Exec(urlID, i, variant.URL, variant.Weight); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return nil
+}
+
+// variants gets weighted destinations of the URL with their click counts
+func (s *Storage) variants(urlID int64) ([]models.Variant, error) {
+	const op = "storage.sqlite.variants"
+
+	rows, err := s.db.Query(`
+		SELECT id, url, weight, clicks
+		FROM url_variant WHERE url_id = ? ORDER BY position`, urlID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var variants []models.Variant
+	for rows.Next() {
+		var variant models.Variant
+		if err = rows.Scan(&variant.ID, &variant.URL, &variant.Weight, &variant.Clicks); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		variants = append(variants, variant)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return variants, nil
+}
@@ -0,0 +1,157 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+
+	"url-shortener/domain/models"
+	"url-shortener/internal/storage"
+)
+
+// SaveWorkspace saves workspace with unique name to db
+func (s *Storage) SaveWorkspace(name string) error {
+	const op = "storage.sqlite.SaveWorkspace"
+
+	_, err := s.db.Exec("INSERT INTO workspace(name, created_at) VALUES(?, ?)", name, time.Now().UTC())
+	if err != nil {
+		var sqliteErr sqlite3.Error
+		if errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique {
+			return fmt.Errorf("%s: %w", op, storage.ErrWorkspaceExists)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Workspace gets workspace by name from db
+func (s *Storage) Workspace(name string) (models.Workspace, error) {
+	const op = "storage.sqlite.Workspace"
+
+	var ws models.Workspace
+	err := s.db.QueryRow("SELECT id, name, created_at FROM workspace WHERE name = ?", name).
+		Scan(&ws.ID, &ws.Name, &ws.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Workspace{}, fmt.Errorf("%s: %w", op, storage.ErrWorkspaceNotFound)
+		}
+		return models.Workspace{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return ws, nil
+}
+
+// Workspaces gets all workspaces ordered by name from db
+func (s *Storage) Workspaces() ([]models.Workspace, error) {
+	const op = "storage.sqlite.Workspaces"
+
+	workspaces, err := s.workspaces("SELECT id, name, created_at FROM workspace ORDER BY name")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return workspaces, nil
+}
+
+// MemberWorkspaces gets workspaces the user is a member of ordered by name from db
+func (s *Storage) MemberWorkspaces(uid int64) ([]models.Workspace, error) {
+	const op = "storage.sqlite.MemberWorkspaces"
+
+	workspaces, err := s.workspaces(`
+		SELECT w.id, w.name, w.created_at
+		FROM workspace w
+		JOIN workspace_member m ON m.workspace_id = w.id
+		WHERE m.uid = ?
+		ORDER BY w.name`, uid)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return workspaces, nil
+}
+
+func (s *Storage) workspaces(query string, args ...any) ([]models.Workspace, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	var workspaces []models.Workspace
+	for rows.Next() {
+		var ws models.Workspace
+		if err = rows.Scan(&ws.ID, &ws.Name, &ws.CreatedAt); err != nil {
+			return nil, err
+		}
+		workspaces = append(workspaces, ws)
+	}
+
+	return workspaces, rows.Err()
+}
+
+// AddWorkspaceMember adds the user to workspace, adding an existing member is not an error
+func (s *Storage) AddWorkspaceMember(workspaceID, uid int64) error {
+	const op = "storage.sqlite.AddWorkspaceMember"
+
+	_, err := s.db.Exec("INSERT OR IGNORE INTO workspace_member(workspace_id, uid) VALUES(?, ?)", workspaceID, uid)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// DeleteWorkspaceMember removes the user from workspace
+func (s *Storage) DeleteWorkspaceMember(workspaceID, uid int64) error {
+	const op = "storage.sqlite.DeleteWorkspaceMember"
+
+	res, err := s.db.Exec("DELETE FROM workspace_member WHERE workspace_id = ? AND uid = ?", workspaceID, uid)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrWorkspaceMemberNotFound)
+	}
+
+	return nil
+}
+
+// IsWorkspaceMember checks if the user is a member of workspace
+func (s *Storage) IsWorkspaceMember(workspaceID, uid int64) (bool, error) {
+	const op = "storage.sqlite.IsWorkspaceMember"
+
+	var member bool
+	err := s.db.QueryRow(
+		"SELECT EXISTS(SELECT 1 FROM workspace_member WHERE workspace_id = ? AND uid = ?)",
+		workspaceID, uid,
+	).Scan(&member)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return member, nil
+}
+
+// nullWorkspace scans workspace columns of LEFT JOIN
+type nullWorkspace struct {
+	id        sql.NullInt64
+	name      sql.NullString
+	createdAt sql.NullTime
+}
+
+func (w nullWorkspace) workspace() *models.Workspace {
+	if !w.id.Valid {
+		return nil
+	}
+
+	return &models.Workspace{ID: w.id.Int64, Name: w.name.String, CreatedAt: w.createdAt.Time}
+}
@@ -1,6 +1,37 @@
 package storage
 
-import "errors"
+import (
+	"errors"
+	"strings"
+)
+
+// URLFilter narrows the list of links, zero fields don't filter
+type URLFilter struct {
+	WorkspaceID int64
+	// UID limits links to ones owned by the user or shared with the user in workspaces
+	UID    int64
+	Limit  int
+	Offset int
+}
+
+// AliasFold defines which aliases are considered equal, the zero value compares them exactly
+type AliasFold struct {
+	IgnoreCase bool
+	// Replace are old, new pairs of characters applied before case folding as in strings.NewReplacer
+	Replace []string
+}
+
+// Apply returns the form of the alias it is compared in
+func (f AliasFold) Apply(alias string) string {
+	if len(f.Replace) > 0 {
+		alias = strings.NewReplacer(f.Replace...).Replace(alias)
+	}
+	if f.IgnoreCase {
+		alias = strings.ToLower(alias)
+	}
+
+	return alias
+}
 
 var (
 	ErrURLNotFound = errors.New("url not found")
@@ -8,4 +39,22 @@ var (
 	ErrAliasExists = errors.New("alias exists")
 	ErrAppExists   = errors.New("app already exists")
 	ErrAppNotFound = errors.New("app not found")
+	// ErrClientNotEncrypted is returned for credentials stored in plaintext, the migrator rotate-key command encrypts them
+	ErrClientNotEncrypted = errors.New("client credentials are not encrypted")
+	// ErrNoSealer is returned for client credentials of a storage opened without an encryption key
+	ErrNoSealer = errors.New("storage has no encryption key")
+
+	ErrUTMTemplateExists   = errors.New("utm template exists")
+	ErrUTMTemplateNotFound = errors.New("utm template not found")
+	ErrUTMTemplateInUse    = errors.New("utm template is used by links")
+
+	ErrWorkspaceExists         = errors.New("workspace exists")
+	ErrWorkspaceNotFound       = errors.New("workspace not found")
+	ErrWorkspaceMemberNotFound = errors.New("workspace member not found")
+
+	ErrUserExists   = errors.New("user exists")
+	ErrUserNotFound = errors.New("user not found")
+
+	ErrAPIKeyExists   = errors.New("api key exists")
+	ErrAPIKeyNotFound = errors.New("api key not found")
 )
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_url_workspace_id;
+ALTER TABLE url DROP COLUMN workspace_id;
+DROP TABLE IF EXISTS workspace_member;
+DROP TABLE IF EXISTS workspace;
\ No newline at end of file
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS workspace
+(
+    id         INTEGER PRIMARY KEY,
+    name       TEXT      NOT NULL UNIQUE,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS workspace_member
+(
+    workspace_id INTEGER NOT NULL REFERENCES workspace(id) ON DELETE CASCADE,
+    uid          INTEGER NOT NULL,
+    PRIMARY KEY (workspace_id, uid)
+);
+CREATE INDEX IF NOT EXISTS idx_workspace_member_uid ON workspace_member(uid);
+
+ALTER TABLE url ADD COLUMN workspace_id INTEGER REFERENCES workspace(id);
+CREATE INDEX IF NOT EXISTS idx_url_workspace_id ON url(workspace_id);
\ No newline at end of file
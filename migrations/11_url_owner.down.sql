@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_url_owner_uid;
+ALTER TABLE url DROP COLUMN owner_uid;
\ No newline at end of file
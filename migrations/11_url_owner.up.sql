@@ -0,0 +1,2 @@
+ALTER TABLE url ADD COLUMN owner_uid INTEGER NOT NULL DEFAULT 0;
+CREATE INDEX IF NOT EXISTS idx_url_owner_uid ON url(owner_uid);
\ No newline at end of file
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS user
+(
+    id         INTEGER PRIMARY KEY,
+    email      TEXT      NOT NULL UNIQUE,
+    pass_hash  BLOB      NOT NULL,
+    level      INTEGER   NOT NULL DEFAULT 1,
+    created_at TIMESTAMP NOT NULL
+);
\ No newline at end of file
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_api_key_uid;
+DROP TABLE IF EXISTS api_key;
\ No newline at end of file
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS api_key
+(
+    id           INTEGER PRIMARY KEY,
+    uid          INTEGER   NOT NULL,
+    email        TEXT      NOT NULL,
+    name         TEXT      NOT NULL,
+    prefix       TEXT      NOT NULL,
+    hash         BLOB      NOT NULL UNIQUE,
+    scopes       TEXT      NOT NULL,
+    expires_at   TIMESTAMP,
+    last_used_at TIMESTAMP,
+    created_at   TIMESTAMP NOT NULL,
+    UNIQUE (uid, name)
+);
+CREATE INDEX IF NOT EXISTS idx_api_key_uid ON api_key(uid);
\ No newline at end of file
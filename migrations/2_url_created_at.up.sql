@@ -0,0 +1 @@
+ALTER TABLE url ADD COLUMN created_at TIMESTAMP;
\ No newline at end of file
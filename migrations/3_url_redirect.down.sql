@@ -0,0 +1,2 @@
+ALTER TABLE url DROP COLUMN redirect_code;
+ALTER TABLE url DROP COLUMN cache_max_age;
\ No newline at end of file
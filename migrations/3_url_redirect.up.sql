@@ -0,0 +1,2 @@
+ALTER TABLE url ADD COLUMN redirect_code INTEGER NOT NULL DEFAULT 302;
+ALTER TABLE url ADD COLUMN cache_max_age INTEGER NOT NULL DEFAULT -1;
\ No newline at end of file
@@ -0,0 +1,2 @@
+ALTER TABLE url DROP COLUMN passthrough;
+ALTER TABLE url DROP COLUMN query_conflict;
\ No newline at end of file
@@ -0,0 +1,2 @@
+ALTER TABLE url ADD COLUMN passthrough INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE url ADD COLUMN query_conflict TEXT NOT NULL DEFAULT 'destination';
\ No newline at end of file
@@ -0,0 +1,2 @@
+ALTER TABLE url DROP COLUMN utm_template_id;
+DROP TABLE IF EXISTS utm_template;
\ No newline at end of file
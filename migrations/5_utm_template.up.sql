@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS utm_template
+(
+    id       INTEGER PRIMARY KEY,
+    name     TEXT    NOT NULL UNIQUE,
+    source   TEXT    NOT NULL,
+    medium   TEXT    NOT NULL,
+    campaign TEXT    NOT NULL,
+    term     TEXT    NOT NULL DEFAULT '',
+    content  TEXT    NOT NULL DEFAULT ''
+);
+CREATE INDEX IF NOT EXISTS idx_utm_template_name ON utm_template(name);
+
+ALTER TABLE url ADD COLUMN utm_template_id INTEGER REFERENCES utm_template(id);
\ No newline at end of file
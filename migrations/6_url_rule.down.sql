@@ -0,0 +1 @@
+DROP TABLE IF EXISTS url_rule;
\ No newline at end of file
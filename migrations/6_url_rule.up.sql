@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS url_rule
+(
+    id            INTEGER PRIMARY KEY,
+    url_id        INTEGER NOT NULL REFERENCES url(id) ON DELETE CASCADE,
+    position      INTEGER NOT NULL,
+    platform      TEXT    NOT NULL DEFAULT '',
+    language      TEXT    NOT NULL DEFAULT '',
+    starts_at     TIMESTAMP,
+    ends_at       TIMESTAMP,
+    referrer_host TEXT    NOT NULL DEFAULT '',
+    url           TEXT    NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_url_rule_url_id ON url_rule(url_id, position);
\ No newline at end of file
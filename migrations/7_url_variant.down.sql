@@ -0,0 +1 @@
+DROP TABLE IF EXISTS url_variant;
\ No newline at end of file
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS url_variant
+(
+    id       INTEGER PRIMARY KEY,
+    url_id   INTEGER NOT NULL REFERENCES url(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    url      TEXT    NOT NULL,
+    weight   INTEGER NOT NULL,
+    clicks   INTEGER NOT NULL DEFAULT 0
+);
+CREATE INDEX IF NOT EXISTS idx_url_variant_url_id ON url_variant(url_id, position);
\ No newline at end of file
@@ -0,0 +1,11 @@
+ALTER TABLE url_rule ADD COLUMN country TEXT NOT NULL DEFAULT '';
+
+CREATE TABLE IF NOT EXISTS click
+(
+    id         INTEGER PRIMARY KEY,
+    url_id     INTEGER   NOT NULL REFERENCES url(id) ON DELETE CASCADE,
+    variant_id INTEGER REFERENCES url_variant(id) ON DELETE CASCADE,
+    country    TEXT      NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_click_url_id ON click(url_id, country);
\ No newline at end of file
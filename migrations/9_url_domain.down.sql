@@ -0,0 +1,21 @@
+DELETE FROM url WHERE domain <> '';
+
+CREATE TABLE IF NOT EXISTS url_old
+(
+    id              INTEGER PRIMARY KEY,
+    alias           TEXT    NOT NULL UNIQUE,
+    url             TEXT    NOT NULL,
+    created_at      TIMESTAMP,
+    redirect_code   INTEGER NOT NULL DEFAULT 302,
+    cache_max_age   INTEGER NOT NULL DEFAULT -1,
+    passthrough     INTEGER NOT NULL DEFAULT 0,
+    query_conflict  TEXT    NOT NULL DEFAULT 'destination',
+    utm_template_id INTEGER REFERENCES utm_template(id)
+);
+
+INSERT INTO url_old(id, alias, url, created_at, redirect_code, cache_max_age, passthrough, query_conflict, utm_template_id)
+SELECT id, alias, url, created_at, redirect_code, cache_max_age, passthrough, query_conflict, utm_template_id FROM url;
+
+DROP TABLE url;
+ALTER TABLE url_old RENAME TO url;
+CREATE INDEX IF NOT EXISTS idx_alias ON url(alias);
\ No newline at end of file
@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS url_new
+(
+    id              INTEGER PRIMARY KEY,
+    domain          TEXT    NOT NULL DEFAULT '',
+    alias           TEXT    NOT NULL,
+    url             TEXT    NOT NULL,
+    created_at      TIMESTAMP,
+    redirect_code   INTEGER NOT NULL DEFAULT 302,
+    cache_max_age   INTEGER NOT NULL DEFAULT -1,
+    passthrough     INTEGER NOT NULL DEFAULT 0,
+    query_conflict  TEXT    NOT NULL DEFAULT 'destination',
+    utm_template_id INTEGER REFERENCES utm_template(id),
+    UNIQUE (domain, alias)
+);
+
+INSERT INTO url_new(id, alias, url, created_at, redirect_code, cache_max_age, passthrough, query_conflict, utm_template_id)
+SELECT id, alias, url, created_at, redirect_code, cache_max_age, passthrough, query_conflict, utm_template_id FROM url;
+
+DROP TABLE url;
+ALTER TABLE url_new RENAME TO url;
+CREATE INDEX IF NOT EXISTS idx_alias ON url(alias);
\ No newline at end of file